@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+
 	"github.com/spf13/cobra"
 
 	"github.com/eutika/eu-missions-cli/internal/auth"
 	"github.com/eutika/eu-missions-cli/internal/commands"
 	"github.com/eutika/eu-missions-cli/internal/config"
 	"github.com/eutika/eu-missions-cli/internal/services"
+	"github.com/eutika/eu-missions-cli/internal/transport"
+	"github.com/eutika/eu-missions-cli/internal/version"
 )
 
 type CommandDependencies struct {
@@ -17,16 +22,56 @@ type CommandDependencies struct {
 }
 
 func NewRootCommand(deps *CommandDependencies) *cobra.Command {
+	var profile, harPath string
+	var debug bool
+
 	rootCmd := &cobra.Command{
 		Use:     "missions",
 		Short:   "Missions CLI (Command Line Interface)",
-		Version: "1.0.10",
+		Version: version.Current(),
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			// Ask about an untrusted project .env file before anything reads the configuration.
+			if commands.SkipsProjectEnv(cmd) {
+				config.SkipProjectEnv()
+			} else {
+				config.LoadProjectEnv()
+			}
+
+			if profile != "" {
+				config.SetActiveProfile(profile)
+			}
+
+			var debugLog io.Writer
+			if debug {
+				debugLog = cmd.ErrOrStderr()
+			}
+			if err := transport.EnableTracing(debugLog, harPath); err != nil {
+				return fmt.Errorf("🚫 %w", err)
+			}
+
+			return config.ValidateProfileName(config.ActiveProfile())
+		},
 	}
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "",
+		"Perfil de Missions a usar (también con la variable MISSIONS_PROFILE)")
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false,
+		"Muestra las peticiones HTTP y sus respuestas, con los tokens ocultos")
+	rootCmd.PersistentFlags().StringVar(&harPath, "har", "",
+		"Guarda las peticiones HTTP en un archivo HAR para adjuntarlo a una incidencia")
 
 	rootCmd.AddCommand(
 		commands.NewLoginCommand(deps.AuthService),
+		commands.NewLogoutCommand(deps.AuthService),
+		commands.NewAuthCommand(deps.AuthService),
+		commands.NewWhoamiCommand(deps.AuthService),
+		commands.NewProfileCommand(),
+		commands.NewEnvCommand(),
+		commands.NewListCommand(deps.RemoteService),
+		commands.NewShowCommand(deps.RemoteService),
 		commands.NewExecuteCommand(deps.RemoteService, deps.CmdExecutor),
 		commands.NewValidateCommand(deps.RemoteService, deps.CmdExecutor),
+		commands.NewSyncCommand(deps.RemoteService),
+		commands.NewCacheCommand(deps.RemoteService),
 	)
 	rootCmd.SetVersionTemplate("missions version {{.Version}}\n")
 
@@ -35,11 +80,12 @@ func NewRootCommand(deps *CommandDependencies) *cobra.Command {
 
 func Execute() error {
 	cfg := config.NewConfig()
+	authService := auth.NewAuthService(cfg)
 	deps := &CommandDependencies{
 		Config:        cfg,
-		RemoteService: services.NewRemoteService(cfg),
+		RemoteService: services.NewRemoteService(cfg, authService),
 		CmdExecutor:   commands.NewCommandExecutor(cfg),
-		AuthService:   auth.NewAuthService(cfg),
+		AuthService:   authService,
 	}
 
 	return NewRootCommand(deps).Execute()
@@ -5,8 +5,12 @@ go 1.22
 require (
 	github.com/spf13/cobra v1.8.0
 	github.com/zalando/go-keyring v0.2.3
+	golang.org/x/crypto v0.31.0
+	golang.org/x/term v0.27.0
 )
 
+require github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+
 require (
 	github.com/alessio/shellescape v1.4.1 // indirect
 	github.com/danieljoos/wincred v1.2.0 // indirect
@@ -14,5 +18,5 @@ require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/joho/godotenv v1.5.1
 	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/sys v0.8.0 // indirect
+	golang.org/x/sys v0.28.0
 )
@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// hasDesktopSession reports whether a browser opened by the CLI would be visible to the user.
+// SSH sessions (Vagrant included) and containers have no desktop even when the host has one.
+func hasDesktopSession() bool {
+	for _, variable := range []string{"SSH_CONNECTION", "SSH_CLIENT", "SSH_TTY"} {
+		if os.Getenv(variable) != "" {
+			return false
+		}
+	}
+	if isContainer() {
+		return false
+	}
+
+	switch runtime.GOOS {
+	case "darwin", "windows":
+		return true
+	default:
+		return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+	}
+}
+
+// isContainer reports whether the CLI runs inside a Docker, Podman or similar container.
+func isContainer() bool {
+	if os.Getenv("container") != "" {
+		return true
+	}
+	for _, marker := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := os.Stat(marker); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// openBrowser opens url in the default browser without waiting for it to exit. The URL comes from
+// the server, and the system launchers also run programs and open local files, so only web
+// addresses are accepted.
+func openBrowser(url string) error {
+	if err := checkBrowserURL(url); err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Reap the launcher in the background; its exit status says nothing about the browser.
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
+
+// checkBrowserURL accepts only absolute http and https URLs with a host.
+func checkBrowserURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("refusing to open %q in the browser: only http and https URLs are allowed", rawURL)
+	}
+	return nil
+}
+
+// renderQRCode writes content as a QR code drawn with Unicode half blocks, two modules per line.
+// Light modules are drawn filled so the code reads correctly on the usual dark terminal background.
+func renderQRCode(w io.Writer, content string) error {
+	code, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return err
+	}
+
+	bitmap := code.Bitmap()
+	var out strings.Builder
+	for row := 0; row < len(bitmap); row += 2 {
+		out.WriteString("   ")
+		for col := range bitmap[row] {
+			top := bitmap[row][col]
+			bottom := true
+			if row+1 < len(bitmap) {
+				bottom = bitmap[row+1][col]
+			}
+
+			switch {
+			case !top && !bottom:
+				out.WriteString("█")
+			case !top && bottom:
+				out.WriteString("▀")
+			case top && !bottom:
+				out.WriteString("▄")
+			default:
+				out.WriteString(" ")
+			}
+		}
+		out.WriteString("\n")
+	}
+
+	_, err = io.WriteString(w, out.String())
+	return err
+}
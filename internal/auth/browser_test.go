@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/skip2/go-qrcode"
+)
+
+func TestCheckBrowserURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{url: "https://missions.eutika.com/device?user_code=ABCD-EFGH"},
+		{url: "http://localhost:8080/device"},
+		{url: "HTTPS://missions.eutika.com/device"},
+		{url: "file:///etc/passwd", wantErr: true},
+		{url: "javascript:alert(1)", wantErr: true},
+		{url: "calc.exe", wantErr: true},
+		{url: "/device", wantErr: true},
+		{url: "https:///device", wantErr: true},
+		{url: "smb://evil.example/share", wantErr: true},
+		{url: "https://missions.eutika.com/%zz", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := checkBrowserURL(tt.url); (err != nil) != tt.wantErr {
+			t.Errorf("checkBrowserURL(%q) = %v, want error: %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestOpenBrowserRejectsOtherSchemes(t *testing.T) {
+	// Nothing is launched: the URL is refused before choosing the launcher.
+	if err := openBrowser("file:///etc/passwd"); err == nil {
+		t.Error("openBrowser accepted a file URL")
+	}
+}
+
+func TestHasDesktopSession(t *testing.T) {
+	for _, variable := range []string{"SSH_CONNECTION", "SSH_CLIENT", "SSH_TTY", "DISPLAY", "WAYLAND_DISPLAY"} {
+		t.Setenv(variable, "")
+	}
+
+	t.Run("SSH session", func(t *testing.T) {
+		t.Setenv("SSH_CONNECTION", "10.0.2.2 51000 10.0.2.15 22")
+		t.Setenv("DISPLAY", ":0")
+		if hasDesktopSession() {
+			t.Error("hasDesktopSession() = true in an SSH session")
+		}
+	})
+
+	t.Run("container", func(t *testing.T) {
+		t.Setenv("container", "podman")
+		t.Setenv("DISPLAY", ":0")
+		if hasDesktopSession() {
+			t.Error("hasDesktopSession() = true in a container")
+		}
+	})
+
+	if isContainer() {
+		t.Skip("the tests run in a container, which never has a desktop")
+	}
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		return
+	}
+
+	t.Run("no display", func(t *testing.T) {
+		if hasDesktopSession() {
+			t.Error("hasDesktopSession() = true without DISPLAY or WAYLAND_DISPLAY")
+		}
+	})
+	t.Run("Wayland", func(t *testing.T) {
+		t.Setenv("WAYLAND_DISPLAY", "wayland-0")
+		if !hasDesktopSession() {
+			t.Error("hasDesktopSession() = false with WAYLAND_DISPLAY")
+		}
+	})
+}
+
+func TestRenderQRCode(t *testing.T) {
+	const content = "https://missions.eutika.com/device?user_code=ABCD-EFGH"
+	var out strings.Builder
+	if err := renderQRCode(&out, content); err != nil {
+		t.Fatalf("renderQRCode: %v", err)
+	}
+
+	code, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := code.Bitmap()
+
+	// Each line holds two rows of modules; read them back and compare with the code.
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != (len(want)+1)/2 {
+		t.Fatalf("lines = %d, want %d for %d rows of modules", len(lines), (len(want)+1)/2, len(want))
+	}
+	for i, line := range lines {
+		cells := []rune(strings.TrimPrefix(line, "   "))
+		if len(cells) != len(want[0]) {
+			t.Fatalf("line %d has %d cells, want %d", i, len(cells), len(want[0]))
+		}
+		for col, cell := range cells {
+			top, bottom := want[2*i][col], true
+			if 2*i+1 < len(want) {
+				bottom = want[2*i+1][col]
+			}
+			// Dark modules are blank and light ones are drawn, for dark terminal backgrounds.
+			var wantCell rune
+			switch {
+			case !top && !bottom:
+				wantCell = '█'
+			case !top:
+				wantCell = '▀'
+			case !bottom:
+				wantCell = '▄'
+			default:
+				wantCell = ' '
+			}
+			if cell != wantCell {
+				t.Fatalf("line %d, column %d = %q, want %q", i, col, cell, wantCell)
+			}
+		}
+	}
+}
+
+func TestDescribeLoginError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantNil bool
+	}{
+		{name: "cancelled", err: NewLoginCancelledError(errors.New("interrupt"))},
+		{name: "access denied", err: NewAccessDeniedError()},
+		{name: "code expired", err: NewDeviceCodeExpiredError()},
+		{name: "polling error", err: NewTokenPollingError(errors.New("timeout")), wantNil: true},
+		{name: "other error", err: errors.New("boom"), wantNil: true},
+	}
+
+	for _, tt := range tests {
+		if got := describeLoginError(tt.err); (got == nil) != tt.wantNil {
+			t.Errorf("%s: describeLoginError = %v, want nil: %v", tt.name, got, tt.wantNil)
+		}
+	}
+}
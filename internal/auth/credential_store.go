@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// CredentialStore is a backend able to persist the CLI credentials.
+type CredentialStore interface {
+	// Name returns the name the backend is registered with.
+	Name() string
+	// Get retrieves a value, returning ErrCredentialNotFound when the key was never stored.
+	Get(key string) (string, error)
+	// Set stores a value.
+	Set(key, value string) error
+	// Delete removes a value, returning ErrCredentialNotFound when the key was never stored.
+	Delete(key string) error
+}
+
+// clearableStore is implemented by backends able to remove every credential at once.
+type clearableStore interface {
+	Clear() error
+}
+
+// CredentialStoreFactory creates a credential store for the given keyring service name.
+type CredentialStoreFactory func(service string) (CredentialStore, error)
+
+// Built-in credential store names.
+const (
+	BackendKeyring = "keyring"
+	BackendFile    = "file"
+	BackendMemory  = "memory"
+	BackendEnv     = "env"
+	BackendHelper  = "helper"
+)
+
+var (
+	// ErrCredentialNotFound is returned when a key does not exist in a credential store.
+	ErrCredentialNotFound = errors.New("credential not found")
+	// ErrReadOnlyStore is returned when writing to a credential store that can only be read.
+	ErrReadOnlyStore = errors.New("credential store is read-only")
+)
+
+var (
+	credentialStoresMu sync.RWMutex
+	credentialStores   = map[string]CredentialStoreFactory{
+		BackendKeyring: newKeyringStore,
+		BackendFile:    newFileStore,
+		BackendMemory:  newMemoryStore,
+		BackendEnv:     newEnvStore,
+		BackendHelper:  newHelperStore,
+	}
+)
+
+// RegisterCredentialStore makes a credential store available under the given name,
+// replacing any store previously registered with it.
+func RegisterCredentialStore(name string, factory CredentialStoreFactory) {
+	credentialStoresMu.Lock()
+	defer credentialStoresMu.Unlock()
+	credentialStores[name] = factory
+}
+
+// CredentialStoreNames returns the names of every registered credential store, sorted.
+func CredentialStoreNames() []string {
+	credentialStoresMu.RLock()
+	defer credentialStoresMu.RUnlock()
+
+	names := make([]string, 0, len(credentialStores))
+	for name := range credentialStores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewCredentialStore creates the credential store registered under name.
+func NewCredentialStore(name, service string) (CredentialStore, error) {
+	credentialStoresMu.RLock()
+	factory, exists := credentialStores[name]
+	credentialStoresMu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("unknown credential store %q, available stores: %v", name, CredentialStoreNames())
+	}
+	return factory(service)
+}
+
+// selectCredentialStore creates the configured credential store. When none is configured the
+// system keyring is used if it is available, and the encrypted file otherwise. The second
+// return value reports whether the encrypted file was picked because the keyring is missing.
+func selectCredentialStore(name, service string) (CredentialStore, bool, error) {
+	if name != "" {
+		store, err := NewCredentialStore(name, service)
+		return store, false, err
+	}
+
+	// Allow forcing file storage for testing purposes
+	if os.Getenv("MISSIONS_CLI_FORCE_FILE_STORAGE") != "true" && isKeyringAvailable(service) {
+		store, err := newKeyringStore(service)
+		return store, false, err
+	}
+
+	store, err := newFileStore(service)
+	return store, true, err
+}
@@ -0,0 +1,260 @@
+package auth
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+)
+
+// keysOnlyStore hides the Clear method of a memory store, like the system keyring that can't be enumerated.
+type keysOnlyStore struct {
+	store *memoryStore
+}
+
+func (s keysOnlyStore) Name() string                   { return s.store.Name() }
+func (s keysOnlyStore) Get(key string) (string, error) { return s.store.Get(key) }
+func (s keysOnlyStore) Set(key, value string) error    { return s.store.Set(key, value) }
+func (s keysOnlyStore) Delete(key string) error        { return s.store.Delete(key) }
+
+func newMemoryTestStore() *memoryStore {
+	return &memoryStore{data: make(map[string]string)}
+}
+
+// useTestStorage makes getStorage return a SecureStorage on top of store for the rest of the test.
+func useTestStorage(t *testing.T, store CredentialStore) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	storageOnce.Do(func() {})
+	storageInstance = &SecureStorage{store: store}
+	t.Cleanup(func() {
+		storageInstance = nil
+		storageOnce = sync.Once{}
+		config.SetActiveProfile("")
+	})
+}
+
+func TestNewCredentialStore(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend string
+		want    string
+		wantErr bool
+	}{
+		{name: "memory", backend: BackendMemory, want: BackendMemory},
+		{name: "file", backend: BackendFile, want: BackendFile},
+		{name: "env", backend: BackendEnv, want: BackendEnv},
+		{name: "unknown", backend: "vault", wantErr: true},
+		{name: "empty", backend: "", wantErr: true},
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewCredentialStore(tt.backend, "missions-cli-test")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NewCredentialStore(%q) returned %s, want an error", tt.backend, store.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewCredentialStore(%q): %v", tt.backend, err)
+			}
+			if store.Name() != tt.want {
+				t.Errorf("NewCredentialStore(%q).Name() = %q, want %q", tt.backend, store.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterCredentialStore(t *testing.T) {
+	const name = "test-registry"
+	RegisterCredentialStore(name, func(_ string) (CredentialStore, error) {
+		return newMemoryTestStore(), nil
+	})
+	t.Cleanup(func() {
+		credentialStoresMu.Lock()
+		delete(credentialStores, name)
+		credentialStoresMu.Unlock()
+	})
+
+	names := CredentialStoreNames()
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("CredentialStoreNames() = %v, want sorted names", names)
+	}
+	found := false
+	for _, registered := range names {
+		found = found || registered == name
+	}
+	if !found {
+		t.Fatalf("CredentialStoreNames() = %v, want it to include %q", names, name)
+	}
+
+	store, err := NewCredentialStore(name, "missions-cli-test")
+	if err != nil {
+		t.Fatalf("NewCredentialStore(%q): %v", name, err)
+	}
+	if store.Name() != BackendMemory {
+		t.Errorf("registered store name = %q, want %q", store.Name(), BackendMemory)
+	}
+}
+
+func TestSelectCredentialStore(t *testing.T) {
+	tests := []struct {
+		name         string
+		backend      string
+		forceFile    string
+		want         string
+		wantFallback bool
+		wantErr      bool
+	}{
+		{name: "configured backend", backend: BackendMemory, want: BackendMemory},
+		{name: "configured backend ignores forced file", backend: BackendMemory, forceFile: "true", want: BackendMemory},
+		{name: "fallback to file", backend: "", forceFile: "true", want: BackendFile, wantFallback: true},
+		{name: "unknown backend", backend: "vault", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("MISSIONS_CLI_FORCE_FILE_STORAGE", tt.forceFile)
+
+			store, fallback, err := selectCredentialStore(tt.backend, "missions-cli-test")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("selectCredentialStore returned no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectCredentialStore: %v", err)
+			}
+			if store.Name() != tt.want {
+				t.Errorf("store = %q, want %q", store.Name(), tt.want)
+			}
+			if fallback != tt.wantFallback {
+				t.Errorf("fallback = %v, want %v", fallback, tt.wantFallback)
+			}
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := newMemoryTestStore()
+
+	if _, err := store.Get("missing"); !errors.Is(err, ErrCredentialNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrCredentialNotFound", err)
+	}
+	if err := store.Delete("missing"); !errors.Is(err, ErrCredentialNotFound) {
+		t.Errorf("Delete(missing) error = %v, want ErrCredentialNotFound", err)
+	}
+
+	if err := store.Set("key", "value"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if value, err := store.Get("key"); err != nil || value != "value" {
+		t.Errorf("Get(key) = %q, %v, want %q", value, err, "value")
+	}
+	if err := store.Delete("key"); err != nil {
+		t.Errorf("Delete(key): %v", err)
+	}
+	if _, err := store.Get("key"); !errors.Is(err, ErrCredentialNotFound) {
+		t.Errorf("Get after Delete error = %v, want ErrCredentialNotFound", err)
+	}
+}
+
+func TestProfileKey(t *testing.T) {
+	tests := []struct {
+		profile string
+		key     string
+		want    string
+	}{
+		{profile: config.DefaultProfile, key: accessTokenKey, want: accessTokenKey},
+		{profile: "work", key: accessTokenKey, want: "profile:work:access_token"},
+		{profile: "staging-eu", key: refreshTokenKey, want: "profile:staging-eu:refresh_token"},
+		{profile: "ci_2", key: tokenBindingKey, want: "profile:ci_2:token_binding"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.profile+"/"+tt.key, func(t *testing.T) {
+			if got := profileKey(tt.profile, tt.key); got != tt.want {
+				t.Errorf("profileKey(%q, %q) = %q, want %q", tt.profile, tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileStorageNamespacing(t *testing.T) {
+	store := newMemoryTestStore()
+	useTestStorage(t, store)
+
+	values := map[string]string{config.DefaultProfile: "default-token", "work": "work-token", "home": "home-token"}
+	for profile, value := range values {
+		if err := getProfileStorage(profile).Set(accessTokenKey, value); err != nil {
+			t.Fatalf("Set for %s: %v", profile, err)
+		}
+	}
+
+	for profile, value := range values {
+		if got, err := getProfileStorage(profile).Get(accessTokenKey); err != nil || got != value {
+			t.Errorf("Get for %s = %q, %v, want %q", profile, got, err, value)
+		}
+		if got := store.data[profileKey(profile, accessTokenKey)]; got != value {
+			t.Errorf("stored key %q = %q, want %q", profileKey(profile, accessTokenKey), got, value)
+		}
+	}
+
+	if err := getProfileStorage("work").Delete(accessTokenKey); err != nil {
+		t.Fatalf("Delete for work: %v", err)
+	}
+	if _, err := getProfileStorage("work").Get(accessTokenKey); !errors.Is(err, ErrCredentialNotFound) {
+		t.Errorf("Get for work after Delete error = %v, want ErrCredentialNotFound", err)
+	}
+	if got, _ := getProfileStorage("home").Get(accessTokenKey); got != "home-token" {
+		t.Errorf("deleting work removed the home session: got %q", got)
+	}
+}
+
+func TestDeleteAllTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		store func() CredentialStore
+	}{
+		{name: "clearable store", store: func() CredentialStore { return newMemoryTestStore() }},
+		{name: "store without Clear", store: func() CredentialStore { return keysOnlyStore{newMemoryTestStore()} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := tt.store()
+			useTestStorage(t, store)
+			if err := config.EnsureProfile("work"); err != nil {
+				t.Fatalf("EnsureProfile: %v", err)
+			}
+			config.SetActiveProfile("unlisted")
+
+			for _, profile := range []string{config.DefaultProfile, "work", "unlisted"} {
+				for _, key := range tokenKeys {
+					if err := getProfileStorage(profile).Set(key, profile+"-"+key); err != nil {
+						t.Fatalf("Set %s for %s: %v", key, profile, err)
+					}
+				}
+			}
+
+			if err := DeleteAllTokens(); err != nil {
+				t.Fatalf("DeleteAllTokens: %v", err)
+			}
+
+			for _, profile := range []string{config.DefaultProfile, "work", "unlisted"} {
+				for _, key := range tokenKeys {
+					if value, err := getProfileStorage(profile).Get(key); !errors.Is(err, ErrCredentialNotFound) {
+						t.Errorf("%s of %s = %q, %v after DeleteAllTokens, want ErrCredentialNotFound", key, profile, value, err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/eutika/eu-missions-cli/internal/config"
+	"github.com/eutika/eu-missions-cli/internal/transport"
 )
 
 type DeviceCodeResponse struct {
@@ -20,7 +21,7 @@ type DeviceCodeResponse struct {
 	Interval                int    `json:"interval"`
 }
 
-func RequestDeviceCode() (*DeviceCodeResponse, error) {
+func RequestDeviceCode(ctx context.Context) (*DeviceCodeResponse, error) {
 	payload := map[string]string{
 		"client_id": config.NewConfig().GetClientID(),
 	}
@@ -31,7 +32,7 @@ func RequestDeviceCode() (*DeviceCodeResponse, error) {
 	}
 
 	const requestTimeout = 10 * time.Second
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
 	defer cancel()
 
 	deviceCodeURL := config.NewConfig().GetDeviceCodeURL()
@@ -41,7 +42,10 @@ func RequestDeviceCode() (*DeviceCodeResponse, error) {
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
+	client, err := transport.NewClient(config.NewConfig())
+	if err != nil {
+		return nil, NewDeviceCodeError(fmt.Errorf("error configuring HTTP client: %w", err))
+	}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, NewDeviceCodeError(fmt.Errorf("error sending device code request: %w", err))
@@ -1,6 +1,9 @@
 package auth
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 // AuthenticationError represents specific authentication-related errors.
 type AuthenticationError struct {
@@ -16,12 +19,27 @@ func (e *AuthenticationError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Code, e.Message)
 }
 
+// Unwrap returns the underlying error so callers can use errors.Is and errors.As.
+func (e *AuthenticationError) Unwrap() error {
+	return e.Err
+}
+
 // Error codes for authentication.
 const (
 	ErrDeviceCodeRequest = "DEVICE_CODE_REQUEST_ERROR"
 	ErrAuthPolling       = "ERROR_AUTH_POLLING"
 	ErrSaveToken         = "SAVE_TOKEN_ERROR"
 	ErrTokenRefresh      = "TOKEN_REFRESH_ERROR" // #nosec G101
+	ErrNotAuthenticated  = "NOT_AUTHENTICATED"
+	ErrSessionExpired    = "SESSION_EXPIRED"
+	ErrTokenRevocation   = "TOKEN_REVOCATION_ERROR" // #nosec G101
+	ErrDeleteToken       = "DELETE_TOKEN_ERROR"
+	ErrAuthorization     = "AUTHORIZATION_ERROR"
+	ErrAccessDenied      = "ACCESS_DENIED"
+	ErrDeviceCodeExpired = "DEVICE_CODE_EXPIRED"
+	ErrLoginCancelled    = "LOGIN_CANCELLED"
+	ErrUnexpectedToken   = "UNEXPECTED_TOKEN_RESPONSE" // #nosec G101
+	ErrTokenOrigin       = "TOKEN_ORIGIN_MISMATCH"     // #nosec G101
 )
 
 // NewDeviceCodeError creates a new authentication error for device code request failures.
@@ -59,3 +77,111 @@ func NewTokenRefreshError(err error) *AuthenticationError {
 		Err:     err,
 	}
 }
+
+// NewNotAuthenticatedError creates a new authentication error for when no session is stored.
+func NewNotAuthenticatedError(err error) *AuthenticationError {
+	return &AuthenticationError{
+		Code:    ErrNotAuthenticated,
+		Message: "Not logged in, please run 'missions login'",
+		Err:     err,
+	}
+}
+
+// NewSessionExpiredError creates a new authentication error for sessions that can no longer be refreshed.
+func NewSessionExpiredError(err error) *AuthenticationError {
+	return &AuthenticationError{
+		Code:    ErrSessionExpired,
+		Message: "Session expired, please run 'missions login' again",
+		Err:     err,
+	}
+}
+
+// NewTokenRevocationError creates a new authentication error for token revocation failures.
+func NewTokenRevocationError(err error) *AuthenticationError {
+	return &AuthenticationError{
+		Code:    ErrTokenRevocation,
+		Message: "Failed to revoke authentication token",
+		Err:     err,
+	}
+}
+
+// NewTokenDeletingError creates a new authentication error for failures removing stored tokens.
+func NewTokenDeletingError(err error) *AuthenticationError {
+	return &AuthenticationError{
+		Code:    ErrDeleteToken,
+		Message: "Failed to delete authentication tokens",
+		Err:     err,
+	}
+}
+
+// NewAuthorizationError creates a new authentication error for authorization code flow failures.
+func NewAuthorizationError(err error) *AuthenticationError {
+	return &AuthenticationError{
+		Code:    ErrAuthorization,
+		Message: "Failed to authorize in the browser",
+		Err:     err,
+	}
+}
+
+// NewAccessDeniedError creates a new authentication error for when the user rejects the authorization.
+func NewAccessDeniedError() *AuthenticationError {
+	return &AuthenticationError{
+		Code:    ErrAccessDenied,
+		Message: "The authorization request was denied",
+	}
+}
+
+// NewDeviceCodeExpiredError creates a new authentication error for device codes that expired before approval.
+func NewDeviceCodeExpiredError() *AuthenticationError {
+	return &AuthenticationError{
+		Code:    ErrDeviceCodeExpired,
+		Message: "The device code expired before the login was completed",
+	}
+}
+
+// NewLoginCancelledError creates a new authentication error for logins interrupted by the user.
+func NewLoginCancelledError(err error) *AuthenticationError {
+	return &AuthenticationError{
+		Code:    ErrLoginCancelled,
+		Message: "Login cancelled",
+		Err:     err,
+	}
+}
+
+// NewUnexpectedTokenResponseError creates a new authentication error for token responses the CLI can't handle.
+func NewUnexpectedTokenResponseError(err error) *AuthenticationError {
+	return &AuthenticationError{
+		Code:    ErrUnexpectedToken,
+		Message: "Unexpected response from the token endpoint",
+		Err:     err,
+	}
+}
+
+// IsLoginRequired reports whether err means the user has to go through an interactive login.
+func IsLoginRequired(err error) bool {
+	var authErr *AuthenticationError
+	if !errors.As(err, &authErr) {
+		return false
+	}
+	return authErr.Code == ErrNotAuthenticated || authErr.Code == ErrSessionExpired
+}
+
+// NewTokenOriginError creates a new authentication error for a request that would send the stored
+// tokens to a server other than the one they were issued for.
+func NewTokenOriginError(expected, target string, err error) *AuthenticationError {
+	message := fmt.Sprintf("The stored session is not valid for %s", target)
+	if expected != "" {
+		message = fmt.Sprintf("The stored session was obtained from %s and will not be sent to %s", expected, target)
+	}
+	return &AuthenticationError{
+		Code:    ErrTokenOrigin,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// IsTokenOriginMismatch reports whether err was caused by a server that does not match the stored session.
+func IsTokenOriginMismatch(err error) bool {
+	var authErr *AuthenticationError
+	return errors.As(err, &authErr) && authErr.Code == ErrTokenOrigin
+}
@@ -1,8 +1,13 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/term"
 
 	"github.com/eutika/eu-missions-cli/internal/config"
 )
@@ -17,9 +22,21 @@ func NewAuthService(cfg *config.Config) *AuthService {
 	}
 }
 
-// Login handles the device code authentication flow.
-func (s *AuthService) Login() error {
-	deviceCode, err := RequestDeviceCode()
+// LoginOptions tunes the interactive login.
+type LoginOptions struct {
+	// NoBrowser disables opening the browser and the QR code, printing only the URL and the code.
+	NoBrowser bool
+	// Web uses the authorization code flow with PKCE and a loopback redirect instead of the device flow.
+	Web bool
+}
+
+// Login handles the device code authentication flow. Cancelling ctx aborts the login.
+func (s *AuthService) Login(ctx context.Context, opts LoginOptions) error {
+	if opts.Web {
+		return s.webLogin(ctx, opts)
+	}
+
+	deviceCode, err := RequestDeviceCode(ctx)
 	if err != nil {
 		fmt.Printf("🚫 No ha sido posible solicitar el código de dispositivo a Missions: %v\n", err)
 		return errors.New("🚫 No sido posible solicitar el código de dispositivo a Missions")
@@ -28,10 +45,18 @@ func (s *AuthService) Login() error {
 	fmt.Println("\n🔐 Iniciando el proceso de autenticación con Missions...")
 	fmt.Printf("\n   1. Accede con tu navegador a: %s\n", deviceCode.VerificationURI)
 	fmt.Printf("   2. Introduce el código: %s\n", deviceCode.UserCode)
-	fmt.Println("\n⏳ Esperando autenticación...")
 
-	token, err := PollForToken(deviceCode)
+	if !opts.NoBrowser {
+		showVerificationURL(deviceCode)
+	}
+
+	stopCountdown := startCountdown(time.Duration(deviceCode.ExpiresIn) * time.Second)
+	token, err := PollForToken(ctx, deviceCode)
+	stopCountdown()
 	if err != nil {
+		if loginErr := describeLoginError(err); loginErr != nil {
+			return loginErr
+		}
 		fmt.Printf("🚫 No ha sido posible obtener el token de autenticación de Missions %s", err)
 		return errors.New("🚫 No ha sido posible obtener el token de autenticación de Missions")
 	}
@@ -43,3 +68,89 @@ func (s *AuthService) Login() error {
 	fmt.Println("\n✅ ¡Enhorabuena, te has autenticado con Missions!")
 	return nil
 }
+
+// describeLoginError turns the polling outcomes the student can act on into a clear message.
+// Other errors get the generic message.
+func describeLoginError(err error) error {
+	var authErr *AuthenticationError
+	if !errors.As(err, &authErr) {
+		return nil
+	}
+
+	switch authErr.Code {
+	case ErrLoginCancelled:
+		return errors.New("⚠️ Se ha cancelado el inicio de sesión")
+	case ErrAccessDenied:
+		return errors.New("🚫 Se ha denegado la autorización en Missions")
+	case ErrDeviceCodeExpired:
+		return errors.New("⌛ El código ha caducado antes de completar la autenticación, ejecuta 'missions login' de nuevo")
+	default:
+		return nil
+	}
+}
+
+// showVerificationURL opens the verification page in the browser when there is a desktop,
+// and draws it as a QR code to scan with a phone otherwise.
+func showVerificationURL(deviceCode *DeviceCodeResponse) {
+	// The complete URI carries the user code, so the student doesn't have to type it.
+	verificationURL := deviceCode.VerificationURIComplete
+	if verificationURL == "" {
+		verificationURL = deviceCode.VerificationURI
+	}
+
+	if hasDesktopSession() {
+		if err := openBrowser(verificationURL); err == nil {
+			fmt.Println("\n🌐 Se ha abierto la página de verificación en tu navegador")
+			return
+		}
+	}
+
+	fmt.Println("\n📱 También puedes escanear este código QR con tu móvil:")
+	fmt.Println()
+	if err := renderQRCode(os.Stdout, verificationURL); err != nil {
+		fmt.Printf("   (no ha sido posible generar el código QR: %v)\n", err)
+	}
+}
+
+// startCountdown shows how long the device code remains valid, refreshing the line every
+// second on a terminal. The returned function stops it and must be called before printing again.
+func startCountdown(validity time.Duration) func() {
+	fd := int(os.Stdout.Fd()) // #nosec G115 -- file descriptors fit in an int.
+	if validity <= 0 || !term.IsTerminal(fd) {
+		fmt.Println("\n⏳ Esperando autenticación...")
+		return func() {}
+	}
+
+	fmt.Println()
+	deadline := time.Now().Add(validity)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			remaining := time.Until(deadline).Round(time.Second)
+			if remaining < 0 {
+				remaining = 0
+			}
+			minutes := int(remaining / time.Minute)
+			seconds := int((remaining % time.Minute) / time.Second)
+			fmt.Printf("\r⏳ Esperando autenticación... (el código caduca en %02d:%02d) ", minutes, seconds)
+
+			select {
+			case <-done:
+				fmt.Println()
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+	}
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxTokenInputSize bounds what LoginWithToken reads; tokens are a few kilobytes at most.
+const maxTokenInputSize = 64 * 1024
+
+// tokenInput is the JSON form accepted by LoginWithToken. expires_at is an alternative to expires_in.
+type tokenInput struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	ExpiresAt    string `json:"expires_at"`
+}
+
+// LoginWithToken stores a token obtained elsewhere, for environments where nobody can complete
+// an interactive login. The input is either a JSON token response or up to three lines: the
+// access token, an optional refresh token and an optional expiry (seconds or an RFC 3339 date).
+// The token is checked against the server before it is saved.
+func (s *AuthService) LoginWithToken(input io.Reader) (*Identity, error) {
+	token, err := readTokenInput(input)
+	if err != nil {
+		return nil, fmt.Errorf("🚫 No se ha podido leer el token: %w", err)
+	}
+
+	identity, err := FetchUserInfo(token.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("🚫 Missions no ha aceptado el token: %w", err)
+	}
+
+	if saveErr := SaveTokens(token); saveErr != nil {
+		return nil, errors.New("🚫 No ha sido posible guardar el token de autenticación de Missions en tu sistema")
+	}
+
+	return identity, nil
+}
+
+// readTokenInput parses the token given to LoginWithToken.
+func readTokenInput(input io.Reader) (*TokenResponse, error) {
+	data, err := io.ReadAll(io.LimitReader(input, maxTokenInputSize))
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+
+	var parsed tokenInput
+	if bytes.HasPrefix(data, []byte("{")) {
+		if unmarshalErr := json.Unmarshal(data, &parsed); unmarshalErr != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", unmarshalErr)
+		}
+	} else if parsed, err = parseTokenLines(data); err != nil {
+		return nil, err
+	}
+
+	parsed.AccessToken = strings.TrimSpace(parsed.AccessToken)
+	if parsed.AccessToken == "" {
+		return nil, errors.New("no access token given")
+	}
+
+	token := &TokenResponse{
+		AccessToken:  parsed.AccessToken,
+		TokenType:    "Bearer",
+		RefreshToken: strings.TrimSpace(parsed.RefreshToken),
+		ExpiresIn:    parsed.ExpiresIn,
+	}
+	if parsed.ExpiresAt != "" {
+		expiresAt, parseErr := time.Parse(time.RFC3339, parsed.ExpiresAt)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid expires_at: %w", parseErr)
+		}
+		token.ExpiresIn = secondsUntil(expiresAt)
+	}
+
+	// Without an explicit lifetime, trust the exp claim of JWT access tokens.
+	if token.ExpiresIn == 0 {
+		if expiresAt, ok := tokenExpiry(token.AccessToken); ok {
+			token.ExpiresIn = secondsUntil(expiresAt)
+		}
+	}
+	if token.ExpiresIn < 0 {
+		return nil, errors.New("the token has already expired")
+	}
+
+	return token, nil
+}
+
+// parseTokenLines reads the line based input: access token, refresh token and expiry.
+// An empty line skips the refresh token.
+func parseTokenLines(data []byte) (tokenInput, error) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+
+	const maxLines = 3
+	if len(lines) > maxLines {
+		return tokenInput{}, fmt.Errorf("expected at most %d lines, got %d", maxLines, len(lines))
+	}
+
+	var parsed tokenInput
+	if len(lines) > 0 {
+		parsed.AccessToken = lines[0]
+	}
+	if len(lines) > 1 {
+		parsed.RefreshToken = lines[1]
+	}
+	if len(lines) > 2 {
+		if seconds, err := strconv.Atoi(lines[2]); err == nil {
+			parsed.ExpiresIn = seconds
+		} else {
+			parsed.ExpiresAt = lines[2]
+		}
+	}
+
+	return parsed, nil
+}
+
+// secondsUntil returns the whole seconds left until t, or -1 once it has passed, as 0 means no known expiry.
+func secondsUntil(t time.Time) int {
+	if seconds := int(time.Until(t).Seconds()); seconds > 0 {
+		return seconds
+	}
+	return -1
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+)
+
+func TestReadTokenInput(t *testing.T) {
+	inOneHour := time.Now().Add(time.Hour)
+	jwt := testJWT(t, map[string]any{"sub": "42", "exp": inOneHour.Unix()})
+	expiredJWT := testJWT(t, map[string]any{"sub": "42", "exp": time.Now().Add(-time.Hour).Unix()})
+
+	tests := []struct {
+		name          string
+		input         string
+		wantAccess    string
+		wantRefresh   string
+		wantExpiresIn int
+		wantErr       bool
+	}{
+		{name: "access token only", input: "access\n", wantAccess: "access"},
+		{name: "access and refresh tokens", input: "access\nrefresh", wantAccess: "access", wantRefresh: "refresh"},
+		{name: "lifetime in seconds", input: "access\nrefresh\n3600\n", wantAccess: "access", wantRefresh: "refresh", wantExpiresIn: 3600},
+		{
+			name:          "expiry date",
+			input:         "access\n\n" + inOneHour.Format(time.RFC3339),
+			wantAccess:    "access",
+			wantExpiresIn: 3600,
+		},
+		{name: "Windows line endings", input: "access\r\nrefresh\r\n", wantAccess: "access", wantRefresh: "refresh"},
+		{
+			name:          "JSON token response",
+			input:         `{"access_token":"access","refresh_token":"refresh","expires_in":600}`,
+			wantAccess:    "access",
+			wantRefresh:   "refresh",
+			wantExpiresIn: 600,
+		},
+		{
+			name:          "JSON with expiry date",
+			input:         `{"access_token":"access","expires_at":"` + inOneHour.Format(time.RFC3339) + `"}`,
+			wantAccess:    "access",
+			wantExpiresIn: 3600,
+		},
+		{name: "expiry from the JWT claims", input: jwt, wantAccess: jwt, wantExpiresIn: 3600},
+		{name: "empty input", input: "  \n", wantErr: true},
+		{name: "empty access token in JSON", input: `{"refresh_token":"refresh"}`, wantErr: true},
+		{name: "too many lines", input: "access\nrefresh\n3600\nextra", wantErr: true},
+		{name: "invalid JSON", input: `{"access_token":`, wantErr: true},
+		{name: "invalid expiry", input: "access\nrefresh\ntomorrow", wantErr: true},
+		{name: "expired date", input: "access\n\n" + time.Now().Add(-time.Hour).Format(time.RFC3339), wantErr: true},
+		{name: "expired JWT", input: expiredJWT, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := readTokenInput(strings.NewReader(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("readTokenInput = %+v, want an error", token)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readTokenInput: %v", err)
+			}
+			if token.AccessToken != tt.wantAccess || token.RefreshToken != tt.wantRefresh {
+				t.Errorf("tokens = %q, %q, want %q, %q", token.AccessToken, token.RefreshToken, tt.wantAccess, tt.wantRefresh)
+			}
+			// Lifetimes computed from a date may be a second short.
+			if token.ExpiresIn < tt.wantExpiresIn-2 || token.ExpiresIn > tt.wantExpiresIn {
+				t.Errorf("ExpiresIn = %d, want %d", token.ExpiresIn, tt.wantExpiresIn)
+			}
+		})
+	}
+}
+
+func TestLoginWithToken(t *testing.T) {
+	store := newMemoryTestStore()
+	useTestStorage(t, store)
+	_, issuer := newUserInfoEndpoint(t, http.StatusOK, `{"sub":"42","preferred_username":"grader"}`)
+	t.Setenv("MISSIONS_CLI_URL", issuer+"/api/cli")
+	t.Setenv("MISSIONS_CLI_TOKEN_URL", issuer+"/token")
+
+	identity, err := NewAuthService(config.NewConfig()).LoginWithToken(strings.NewReader("access\nrefresh\n3600\n"))
+	if err != nil {
+		t.Fatalf("LoginWithToken: %v", err)
+	}
+	if identity.Username != "grader" {
+		t.Errorf("identity = %+v, want the userinfo identity", identity)
+	}
+	if got := storedToken(t, accessTokenKey); got != "access" {
+		t.Errorf("access token = %q, want %q", got, "access")
+	}
+	if got := storedToken(t, refreshTokenKey); got != "refresh" {
+		t.Errorf("refresh token = %q, want %q", got, "refresh")
+	}
+	if err := checkIssuerURL(getSessionStorage(), issuer+"/token"); err != nil {
+		t.Errorf("the session is not bound to the server it was checked against: %v", err)
+	}
+}
+
+func TestLoginWithRejectedToken(t *testing.T) {
+	store := newMemoryTestStore()
+	useTestStorage(t, store)
+	newUserInfoEndpoint(t, http.StatusUnauthorized, `{"error":"invalid_token"}`)
+
+	if _, err := NewAuthService(config.NewConfig()).LoginWithToken(strings.NewReader("forged")); err == nil {
+		t.Fatal("LoginWithToken accepted a token the server rejected")
+	}
+	if len(store.data) != 0 {
+		t.Errorf("stored credentials = %v, want none", store.data)
+	}
+}
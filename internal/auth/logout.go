@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+)
+
+// Logout revokes the stored tokens on the server and removes them from this machine.
+// When all is true the sessions of every profile are revoked and every credential
+// stored by the CLI is wiped, not only the active session.
+func (s *AuthService) Logout(all bool) error {
+	profiles := []string{config.ActiveProfile()}
+	deleteTokens := DeleteTokens
+	if all {
+		stored, err := config.LoadProfiles()
+		if err != nil {
+			fmt.Printf("⚠️  No ha sido posible leer los perfiles: %v\n", err)
+		} else {
+			profiles = stored.Names()
+		}
+		deleteTokens = DeleteAllTokens
+	}
+
+	// Tokens read from environment variables can't be removed by the CLI: refuse before revoking
+	// them, so the session isn't left revoked on the server but still set in the environment.
+	if getStorage().Backend() == BackendEnv {
+		return readOnlyLogoutError(profiles)
+	}
+
+	revoked := true
+	for _, profile := range profiles {
+		if !revokeProfileTokens(profile) {
+			revoked = false
+		}
+	}
+
+	if err := deleteTokens(); err != nil {
+		fmt.Printf("🚫 No ha sido posible eliminar las credenciales guardadas: %v\n", err)
+		return errors.New("🚫 No ha sido posible cerrar la sesión de Missions")
+	}
+
+	if !revoked {
+		fmt.Println("ℹ️  Las credenciales se han eliminado de este equipo, pero podrían seguir siendo válidas en el servidor.")
+	}
+	fmt.Println("👋 Has cerrado la sesión de Missions.")
+	return nil
+}
+
+// readOnlyLogoutError explains that the session of the profiles is given by environment variables,
+// which the user has to unset to log out.
+func readOnlyLogoutError(profiles []string) error {
+	var variables []string
+	for _, profile := range profiles {
+		for _, key := range tokenKeys {
+			variables = append(variables, envVariableName(profileKey(profile, key)))
+		}
+	}
+	return fmt.Errorf("🚫 Las credenciales se leen de variables de entorno (MISSIONS_CLI_CREDENTIAL_STORE=%s) "+
+		"y la CLI no puede eliminarlas, así que no se ha cerrado la sesión.\n"+
+		"💡 Para cerrarla, elimina de tu entorno las variables que uses de entre %s",
+		BackendEnv, strings.Join(variables, ", "))
+}
+
+// revokeProfileTokens revokes the stored tokens of a profile against its own authorization server.
+// It reports whether every stored token was revoked.
+func revokeProfileTokens(profile string) bool {
+	storage := getProfileStorage(profile)
+	cfg := config.NewConfig().ForProfile(profile)
+
+	if err := checkIssuerURL(storage, cfg.GetRevocationURL()); err != nil {
+		if HasProfileSession(profile) {
+			fmt.Printf("⚠️  No se revoca la sesión del perfil %s: %v\n", profile, err)
+			return false
+		}
+		return true
+	}
+
+	revoked := true
+	// Revoke the refresh token first: on most servers that also invalidates the access tokens issued from it.
+	for _, stored := range []struct{ key, hint string }{
+		{refreshTokenKey, refreshTokenHint},
+		{accessTokenKey, accessTokenHint},
+	} {
+		token, err := storage.Get(stored.key)
+		if err != nil || token == "" {
+			continue
+		}
+		if revokeErr := revokeToken(cfg, token, stored.hint); revokeErr != nil {
+			fmt.Printf("⚠️  No ha sido posible revocar la sesión del perfil %s en Missions: %v\n", profile, revokeErr)
+			revoked = false
+		}
+	}
+
+	return revoked
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// revocationEndpoint is a stand-in RFC 7009 revocation endpoint that records the forms it receives.
+type revocationEndpoint struct {
+	mu     sync.Mutex
+	status int
+	forms  []url.Values
+}
+
+func newRevocationEndpoint(t *testing.T, status int) (*revocationEndpoint, string) {
+	t.Helper()
+	endpoint := &revocationEndpoint{status: status}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Content-Type"); got != "application/x-www-form-urlencoded" {
+			t.Errorf("revocation Content-Type = %q, want a form", got)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("revocation body: %v", err)
+		}
+		endpoint.mu.Lock()
+		endpoint.forms = append(endpoint.forms, r.PostForm)
+		endpoint.mu.Unlock()
+		w.WriteHeader(endpoint.status)
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("MISSIONS_CLI_REVOCATION_URL", server.URL+"/revoke")
+	return endpoint, server.URL
+}
+
+func (e *revocationEndpoint) received() []url.Values {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]url.Values(nil), e.forms...)
+}
+
+// storeTestSession stores a session of the active profile issued by issuer.
+func storeTestSession(t *testing.T, issuer string) {
+	t.Helper()
+	storage := getSessionStorage()
+	for key, value := range map[string]string{accessTokenKey: "access", refreshTokenKey: "refresh"} {
+		if err := storage.Set(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := saveTokenBinding(storage, &tokenBinding{Issuer: issuer, API: issuer}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLogoutRevokesAndDeletesSession(t *testing.T) {
+	store := newMemoryTestStore()
+	useTestStorage(t, store)
+	endpoint, issuer := newRevocationEndpoint(t, http.StatusOK)
+	storeTestSession(t, issuer)
+
+	if err := NewAuthService(nil).Logout(false); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+
+	forms := endpoint.received()
+	if len(forms) != 2 {
+		t.Fatalf("revocation requests = %d, want 2", len(forms))
+	}
+	// The refresh token goes first, as revoking it usually invalidates the access tokens too.
+	for i, want := range []struct{ token, hint string }{{"refresh", refreshTokenHint}, {"access", accessTokenHint}} {
+		if forms[i].Get("token") != want.token || forms[i].Get("token_type_hint") != want.hint {
+			t.Errorf("revocation %d = %v, want token %q with hint %q", i+1, forms[i], want.token, want.hint)
+		}
+		if forms[i].Get("client_id") == "" {
+			t.Errorf("revocation %d has no client_id", i+1)
+		}
+	}
+
+	if len(store.data) != 0 {
+		t.Errorf("stored credentials after logout = %v, want none", store.data)
+	}
+}
+
+func TestLogoutDeletesSessionWhenRevocationFails(t *testing.T) {
+	store := newMemoryTestStore()
+	useTestStorage(t, store)
+	endpoint, issuer := newRevocationEndpoint(t, http.StatusServiceUnavailable)
+	storeTestSession(t, issuer)
+
+	if err := NewAuthService(nil).Logout(false); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if len(endpoint.received()) == 0 {
+		t.Error("the session was not revoked")
+	}
+	if len(store.data) != 0 {
+		t.Errorf("stored credentials after logout = %v, want none", store.data)
+	}
+}
+
+func TestLogoutDoesNotRevokeOnAnotherServer(t *testing.T) {
+	store := newMemoryTestStore()
+	useTestStorage(t, store)
+	endpoint, _ := newRevocationEndpoint(t, http.StatusOK)
+	storeTestSession(t, "https://missions.eutika.com")
+
+	if err := NewAuthService(nil).Logout(false); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if forms := endpoint.received(); len(forms) != 0 {
+		t.Errorf("tokens were sent to a revocation endpoint of another server: %v", forms)
+	}
+	if len(store.data) != 0 {
+		t.Errorf("stored credentials after logout = %v, want none", store.data)
+	}
+}
+
+func TestLogoutWithEnvironmentStore(t *testing.T) {
+	useTestStorage(t, &envStore{})
+	endpoint, issuer := newRevocationEndpoint(t, http.StatusOK)
+	t.Setenv("MISSIONS_CLI_ACCESS_TOKEN", "access")
+	t.Setenv("MISSIONS_CLI_REFRESH_TOKEN", "refresh")
+	t.Setenv("MISSIONS_CLI_TOKEN_BINDING", `{"issuer":"`+issuer+`","api":"`+issuer+`"}`)
+
+	err := NewAuthService(nil).Logout(false)
+	if err == nil {
+		t.Fatal("Logout succeeded with credentials it can't delete")
+	}
+	if errors.Is(err, ErrReadOnlyStore) {
+		t.Errorf("Logout error = %v, want an explanation instead of the store error", err)
+	}
+	for _, variable := range []string{"MISSIONS_CLI_ACCESS_TOKEN", "MISSIONS_CLI_REFRESH_TOKEN"} {
+		if !strings.Contains(err.Error(), variable) {
+			t.Errorf("Logout error doesn't name %s: %v", variable, err)
+		}
+	}
+	if forms := endpoint.received(); len(forms) != 0 {
+		t.Errorf("tokens were revoked before refusing to log out: %v", forms)
+	}
+}
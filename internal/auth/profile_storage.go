@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"github.com/eutika/eu-missions-cli/internal/config"
+)
+
+// profileStorage scopes the shared SecureStorage to the credentials of a single profile.
+type profileStorage struct {
+	storage *SecureStorage
+	profile string
+}
+
+// getProfileStorage returns the storage for the credentials of the given profile.
+func getProfileStorage(profile string) *profileStorage {
+	return &profileStorage{
+		storage: getStorage(),
+		profile: profile,
+	}
+}
+
+// getSessionStorage returns the storage for the credentials of the active profile.
+func getSessionStorage() *profileStorage {
+	return getProfileStorage(config.ActiveProfile())
+}
+
+// profileKey namespaces a storage key by profile. The default profile keeps the bare keys
+// so sessions stored before profiles existed remain valid.
+func profileKey(profile, key string) string {
+	if profile == config.DefaultProfile {
+		return key
+	}
+	return "profile:" + profile + ":" + key
+}
+
+// Set stores a value for the profile.
+func (p *profileStorage) Set(key, value string) error {
+	return p.storage.Set(profileKey(p.profile, key), value)
+}
+
+// Get retrieves a value of the profile.
+func (p *profileStorage) Get(key string) (string, error) {
+	return p.storage.Get(profileKey(p.profile, key))
+}
+
+// Delete removes a value of the profile.
+func (p *profileStorage) Delete(key string) error {
+	return p.storage.Delete(profileKey(p.profile, key))
+}
@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+	"github.com/eutika/eu-missions-cli/internal/transport"
+)
+
+// Identity sources reported in Identity.Source.
+const (
+	IdentityFromUserInfo = "userinfo"
+	IdentityFromToken    = "token"
+)
+
+// Identity describes the account a token was issued to.
+type Identity struct {
+	Subject  string `json:"sub"`
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Username string `json:"preferred_username"`
+	Source   string `json:"-"`
+}
+
+// SessionStatus describes the locally stored session.
+type SessionStatus struct {
+	Profile         string
+	Backend         string
+	FallbackPath    string
+	StorageError    error
+	LoggedIn        bool
+	ExpiresAt       time.Time
+	Expired         bool
+	HasRefreshToken bool
+	Identity        *Identity
+	// FromEnvironment is set when the access token comes from MISSIONS_TOKEN rather than storage.
+	FromEnvironment bool
+}
+
+// Usable reports whether the session can be used to talk to Missions, possibly after a refresh.
+func (s *SessionStatus) Usable() bool {
+	return s.LoggedIn && (!s.Expired || s.HasRefreshToken)
+}
+
+// Status inspects the stored session without modifying it.
+func (s *AuthService) Status() *SessionStatus {
+	if token := s.config.GetEnvironmentToken(); token != "" {
+		return environmentTokenStatus(token)
+	}
+
+	storage := getSessionStorage()
+	status := &SessionStatus{
+		Profile:      storage.profile,
+		Backend:      storage.storage.Backend(),
+		FallbackPath: storage.storage.FallbackPath(),
+	}
+	if unavailable, ok := storage.storage.store.(*unavailableStore); ok {
+		status.StorageError = unavailable.err
+	}
+
+	accessToken, err := storage.Get(accessTokenKey)
+	if err != nil && !isNotFound(err) && status.StorageError == nil {
+		status.StorageError = err
+	}
+	if err != nil || accessToken == "" {
+		return status
+	}
+	status.LoggedIn = true
+
+	if expiresAt, expErr := tokenExpiresAt(); expErr == nil {
+		status.ExpiresAt = expiresAt
+	}
+	status.Expired, _ = IsTokenExpired()
+
+	if refreshToken, refreshErr := storage.Get(refreshTokenKey); refreshErr == nil && refreshToken != "" {
+		status.HasRefreshToken = true
+	}
+
+	// Only ask the server about tokens it would still accept, and only the server that issued them;
+	// otherwise fall back to the token claims.
+	if !status.Expired && checkIssuerURL(storage, s.config.GetUserInfoURL()) == nil {
+		if identity, infoErr := FetchUserInfo(accessToken); infoErr == nil {
+			status.Identity = identity
+			return status
+		}
+	}
+	if identity, claimsErr := parseTokenClaims(accessToken); claimsErr == nil {
+		status.Identity = identity
+	}
+
+	return status
+}
+
+// environmentTokenStatus describes a session given through MISSIONS_TOKEN. Storage is not touched.
+func environmentTokenStatus(accessToken string) *SessionStatus {
+	status := &SessionStatus{
+		Profile:         config.ActiveProfile(),
+		LoggedIn:        true,
+		FromEnvironment: true,
+	}
+
+	if expiresAt, ok := tokenExpiry(accessToken); ok {
+		status.ExpiresAt = expiresAt
+		status.Expired = time.Now().After(expiresAt)
+	}
+
+	if identity, err := FetchUserInfo(accessToken); err == nil {
+		status.Identity = identity
+	} else if identity, claimsErr := parseTokenClaims(accessToken); claimsErr == nil {
+		status.Identity = identity
+	}
+
+	return status
+}
+
+// FetchUserInfo retrieves the identity of the token owner from the userinfo endpoint.
+func FetchUserInfo(accessToken string) (*Identity, error) {
+	const requestTimeout = 10 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, config.NewConfig().GetUserInfoURL(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating userinfo request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	client, err := transport.NewClient(config.NewConfig())
+	if err != nil {
+		return nil, fmt.Errorf("error configuring HTTP client: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending userinfo request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("userinfo request failed with status: %d", resp.StatusCode)
+	}
+
+	var identity Identity
+	if decodeErr := json.NewDecoder(resp.Body).Decode(&identity); decodeErr != nil {
+		return nil, fmt.Errorf("error decoding userinfo response: %w", decodeErr)
+	}
+	identity.Source = IdentityFromUserInfo
+
+	return &identity, nil
+}
+
+// parseTokenClaims reads the identity claims of a JWT access token.
+// The signature is not verified: the result is only used for display purposes.
+func parseTokenClaims(accessToken string) (*Identity, error) {
+	var identity Identity
+	if err := decodeTokenClaims(accessToken, &identity); err != nil {
+		return nil, err
+	}
+	identity.Source = IdentityFromToken
+
+	return &identity, nil
+}
+
+// tokenExpiry reads the exp claim of a JWT access token, if there is one.
+func tokenExpiry(accessToken string) (time.Time, bool) {
+	var claims struct {
+		ExpiresAt int64 `json:"exp"`
+	}
+	if err := decodeTokenClaims(accessToken, &claims); err != nil || claims.ExpiresAt == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.ExpiresAt, 0), true
+}
+
+// decodeTokenClaims unmarshals the payload of a JWT into v without verifying its signature.
+func decodeTokenClaims(accessToken string, v any) error {
+	parts := strings.Split(accessToken, ".")
+	const jwtParts = 3
+	if len(parts) != jwtParts {
+		return errors.New("access token is not a JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return fmt.Errorf("error decoding token claims: %w", err)
+	}
+
+	if unmarshalErr := json.Unmarshal(payload, v); unmarshalErr != nil {
+		return fmt.Errorf("error parsing token claims: %w", unmarshalErr)
+	}
+
+	return nil
+}
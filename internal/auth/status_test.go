@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+)
+
+// testJWT returns an unsigned JWT carrying claims.
+func testJWT(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"none"}`)) + "." + encode(payload) + ".signature"
+}
+
+// newUserInfoEndpoint starts a stand-in userinfo endpoint answering with status and body, and
+// returns how many requests it received and its origin.
+func newUserInfoEndpoint(t *testing.T, status int, body string) (*atomic.Int32, string) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if got := r.Header.Get("Authorization"); got == "" {
+			t.Errorf("userinfo request without Authorization header")
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("MISSIONS_CLI_USERINFO_URL", server.URL+"/userinfo")
+	return &requests, server.URL
+}
+
+func setTestToken(t *testing.T, key, value string) {
+	t.Helper()
+	if err := getSessionStorage().Set(key, value); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStatusWithoutSession(t *testing.T) {
+	useTestStorage(t, newMemoryTestStore())
+
+	status := NewAuthService(config.NewConfig()).Status()
+	if status.LoggedIn || status.Usable() {
+		t.Errorf("status = %+v, want no usable session", status)
+	}
+	if status.Backend != BackendMemory || status.StorageError != nil {
+		t.Errorf("storage = %q, %v, want the memory store without errors", status.Backend, status.StorageError)
+	}
+}
+
+func TestStatusIdentityFromUserInfo(t *testing.T) {
+	useTestStorage(t, newMemoryTestStore())
+	requests, issuer := newUserInfoEndpoint(t, http.StatusOK, `{"sub":"42","email":"ana@example.com","name":"Ana"}`)
+	storeTestSession(t, issuer)
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	setTestToken(t, tokenExpiresAtKey, expiresAt.Format(time.RFC3339))
+
+	status := NewAuthService(config.NewConfig()).Status()
+	if !status.LoggedIn || status.Expired || !status.HasRefreshToken || !status.Usable() {
+		t.Errorf("status = %+v, want a usable session with a refresh token", status)
+	}
+	if !status.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("ExpiresAt = %s, want %s", status.ExpiresAt, expiresAt)
+	}
+	if status.Identity == nil || status.Identity.Email != "ana@example.com" || status.Identity.Source != IdentityFromUserInfo {
+		t.Errorf("Identity = %+v, want the userinfo identity", status.Identity)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("userinfo requests = %d, want 1", requests.Load())
+	}
+}
+
+func TestStatusIdentityFromTokenClaims(t *testing.T) {
+	tests := []struct {
+		name         string
+		issuer       string
+		expiresIn    time.Duration
+		userInfo     int
+		wantRequests int32
+	}{
+		{name: "userinfo fails", userInfo: http.StatusInternalServerError, expiresIn: time.Hour, wantRequests: 1},
+		{name: "expired token", userInfo: http.StatusOK, expiresIn: -time.Hour},
+		{name: "session of another server", issuer: "https://missions.eutika.com", userInfo: http.StatusOK, expiresIn: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestStorage(t, newMemoryTestStore())
+			requests, server := newUserInfoEndpoint(t, tt.userInfo, `{"email":"userinfo@example.com"}`)
+			issuer := tt.issuer
+			if issuer == "" {
+				issuer = server
+			}
+			storeTestSession(t, issuer)
+			setTestToken(t, accessTokenKey, testJWT(t, map[string]any{"sub": "42", "email": "ana@example.com"}))
+			setTestToken(t, tokenExpiresAtKey, time.Now().Add(tt.expiresIn).Format(time.RFC3339))
+
+			status := NewAuthService(config.NewConfig()).Status()
+			if status.Identity == nil || status.Identity.Email != "ana@example.com" || status.Identity.Source != IdentityFromToken {
+				t.Errorf("Identity = %+v, want the identity of the token claims", status.Identity)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("userinfo requests = %d, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestSessionStatusUsable(t *testing.T) {
+	tests := []struct {
+		name   string
+		status SessionStatus
+		want   bool
+	}{
+		{"not logged in", SessionStatus{}, false},
+		{"valid", SessionStatus{LoggedIn: true}, true},
+		{"expired with refresh token", SessionStatus{LoggedIn: true, Expired: true, HasRefreshToken: true}, true},
+		{"expired without refresh token", SessionStatus{LoggedIn: true, Expired: true}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.status.Usable(); got != tt.want {
+			t.Errorf("%s: Usable() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEnvironmentTokenStatus(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	newUserInfoEndpoint(t, http.StatusUnauthorized, "")
+
+	expiresAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	token := testJWT(t, map[string]any{"preferred_username": "ana", "exp": expiresAt.Unix()})
+
+	status := environmentTokenStatus(token)
+	if !status.FromEnvironment || !status.LoggedIn {
+		t.Errorf("status = %+v, want a session from MISSIONS_TOKEN", status)
+	}
+	if !status.ExpiresAt.Equal(expiresAt) || !status.Expired || status.Usable() {
+		t.Errorf("status = %+v, want it expired at %s", status, expiresAt)
+	}
+	if status.Identity == nil || status.Identity.Username != "ana" {
+		t.Errorf("Identity = %+v, want the identity of the token claims", status.Identity)
+	}
+}
+
+func TestParseTokenClaimsRejectsOpaqueTokens(t *testing.T) {
+	for _, token := range []string{"opaque-token", "a.b", "a.!!!.c", "a." + base64.RawURLEncoding.EncodeToString([]byte("[]")) + ".c"} {
+		if identity, err := parseTokenClaims(token); err == nil {
+			t.Errorf("parseTokenClaims(%q) = %+v, want an error", token, identity)
+		}
+		if _, ok := tokenExpiry(token); ok {
+			t.Errorf("tokenExpiry(%q) reported an expiry", token)
+		}
+	}
+}
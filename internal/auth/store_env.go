@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"os"
+	"strings"
+)
+
+// envStore reads credentials from environment variables. It is read-only: the variable
+// for a key is MISSIONS_CLI_ followed by the key in upper case, with every character that
+// is not a letter or a digit replaced by '_' (access_token is MISSIONS_CLI_ACCESS_TOKEN).
+type envStore struct{}
+
+// newEnvStore creates a read-only store backed by environment variables.
+func newEnvStore(_ string) (CredentialStore, error) {
+	return &envStore{}, nil
+}
+
+// envVariableName returns the environment variable holding a key.
+func envVariableName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, key)
+	return "MISSIONS_CLI_" + strings.ToUpper(name)
+}
+
+// Name returns the backend name.
+func (s *envStore) Name() string {
+	return BackendEnv
+}
+
+// Get retrieves a value from the environment.
+func (s *envStore) Get(key string) (string, error) {
+	value, exists := os.LookupEnv(envVariableName(key))
+	if !exists {
+		return "", ErrCredentialNotFound
+	}
+	return value, nil
+}
+
+// Set always fails: the environment can't be changed for the calling shell.
+func (s *envStore) Set(_, _ string) error {
+	return ErrReadOnlyStore
+}
+
+// Delete always fails: the environment can't be changed for the calling shell.
+func (s *envStore) Delete(_ string) error {
+	return ErrReadOnlyStore
+}
@@ -0,0 +1,485 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+)
+
+// fileStore keeps credentials in an AES-GCM encrypted file in the user config directory.
+// It is the fallback used when the system keyring is not available.
+type fileStore struct {
+	service string
+	path    string
+
+	// usePassphrase derives the file key from a user passphrase instead of the machine identity.
+	usePassphrase bool
+	keyCache      *sessionKeyCache
+	key           []byte
+	params        *scryptParam
+}
+
+// newFileStore creates a file store in the default fallback location.
+func newFileStore(service string) (CredentialStore, error) {
+	cfg := config.NewConfig()
+	path := getFallbackPath()
+
+	return &fileStore{
+		service:       service,
+		path:          path,
+		usePassphrase: cfg.GetFileEncryption() == config.FileEncryptionPassphrase,
+		keyCache:      newSessionKeyCache(path, cfg.GetPassphraseCacheTTL()),
+	}, nil
+}
+
+// getFallbackPath returns the path for the fallback storage file.
+func getFallbackPath() string {
+	configDir := config.GetConfigDir()
+	// Ensure directory exists
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		// If we can't create the directory, fall back to temp
+		return filepath.Join(os.TempDir(), ".missions-cli-tokens")
+	}
+
+	return filepath.Join(configDir, ".tokens")
+}
+
+// Name returns the backend name.
+func (s *fileStore) Name() string {
+	return BackendFile
+}
+
+// Path returns the location of the encrypted file.
+func (s *fileStore) Path() string {
+	return s.path
+}
+
+// Clear removes the encrypted file and every credential in it.
+func (s *fileStore) Clear() error {
+	lock, err := acquireFileLock(s.path)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	return s.removeFile()
+}
+
+// removeFile deletes the encrypted file and forgets its key. The caller must hold the file lock.
+func (s *fileStore) removeFile() error {
+	s.key = nil
+	s.keyCache.clear()
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// printSecurityWarning displays a warning when using fallback storage.
+func (s *fileStore) printSecurityWarning() {
+	fmt.Println()
+	fmt.Println("⚠️  Aviso de Seguridad")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("El keyring del sistema no está disponible en este entorno.")
+	fmt.Printf("Los tokens se guardarán cifrados en: %s\n", s.path)
+	fmt.Println()
+	fmt.Println("💡 Para mayor seguridad, considera configurar un keyring del sistema:")
+
+	switch runtime.GOOS {
+	case "linux":
+		fmt.Println("   • Instala gnome-keyring: sudo apt-get install gnome-keyring")
+		fmt.Println("   • O usa KWallet si estás en KDE")
+		fmt.Println("   • Luego ejecuta: eval $(dbus-launch --sh-syntax)")
+	case "darwin":
+		fmt.Println("   • Verifica que Keychain esté funcionando correctamente")
+		fmt.Println("   • Si estás en SSH, puede que Keychain no esté accesible")
+	case "windows":
+		fmt.Println("   • Verifica que Credential Manager esté funcionando correctamente")
+	}
+
+	fmt.Println()
+	fmt.Println("ℹ️  Más información: https://github.com/eutika/eu-missions-cli#security")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+}
+
+type tokenStore struct {
+	Data map[string]string `json:"data"`
+}
+
+// getEncryptionKey derives an encryption key from machine-specific data.
+func (s *fileStore) getEncryptionKey() []byte {
+	// Use hostname and username as seed for encryption key
+	hostname, _ := os.Hostname()
+	username := os.Getenv("USER")
+	if username == "" {
+		username = os.Getenv("USERNAME")
+	}
+
+	// Create a deterministic key based on machine identity
+	seed := fmt.Sprintf("%s:%s:%s", s.service, hostname, username)
+	hash := sha256.Sum256([]byte(seed))
+	return hash[:]
+}
+
+// encrypt encrypts data using AES-GCM.
+func encrypt(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
+	return ciphertext, nil
+}
+
+// decrypt decrypts data using AES-GCM.
+func decrypt(key, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(ciphertext) < nonceSize+gcm.Overhead() {
+		return nil, fmt.Errorf("%w: ciphertext too short", errCorruptTokenFile)
+	}
+
+	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return plaintext, nil
+}
+
+// Token file formats. Version 1 files are a bare base64 blob encrypted with the machine key;
+// version 2 files are a JSON envelope that records how the key is derived.
+const (
+	tokenFileVersion = 2
+	kdfMachine       = "machine"
+	kdfScrypt        = "scrypt"
+)
+
+// tokenFile is the on-disk envelope of a version 2 token file.
+type tokenFile struct {
+	Version int          `json:"version"`
+	KDF     string       `json:"kdf"`
+	Scrypt  *scryptParam `json:"scrypt,omitempty"`
+	Data    string       `json:"data"`
+}
+
+// parseTokenFile reads the envelope of a token file, recognising legacy version 1 files.
+func parseTokenFile(data []byte) (*tokenFile, error) {
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(trimmed, []byte("{")) {
+		return &tokenFile{Version: 1, KDF: kdfMachine, Data: string(trimmed)}, nil
+	}
+
+	var file tokenFile
+	if err := json.Unmarshal(trimmed, &file); err != nil {
+		return nil, fmt.Errorf("%w: %w", errCorruptTokenFile, err)
+	}
+	if file.Version > tokenFileVersion {
+		return nil, fmt.Errorf("unsupported token file version %d, please update the CLI", file.Version)
+	}
+	if file.KDF == kdfScrypt && file.Scrypt == nil {
+		return nil, fmt.Errorf("%w: missing key derivation parameters", errCorruptTokenFile)
+	}
+
+	return &file, nil
+}
+
+// errCorruptTokenFile marks a token file that can no longer be read.
+var errCorruptTokenFile = errors.New("token file is damaged")
+
+// errTokenKeyMismatch marks an intact token file that can't be decrypted with the machine key,
+// because the host name or the user changed since it was written. The file is left alone: the
+// session comes back once the machine key matches again.
+var errTokenKeyMismatch = errors.New("the token file was encrypted for another host name or user, " +
+	"run 'missions login' to sign in again")
+
+// loadStore loads the token store from disk, migrating it to the configured format if needed.
+// The caller must hold the file lock.
+func (s *fileStore) loadStore() (*tokenStore, error) {
+	store, err := s.readStore()
+	if errors.Is(err, errCorruptTokenFile) {
+		return s.discardCorruptFile(err)
+	}
+	return store, err
+}
+
+// readStore reads and decrypts the token file.
+func (s *fileStore) readStore() (*tokenStore, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &tokenStore{Data: make(map[string]string)}, nil
+		}
+		return nil, err
+	}
+
+	file, err := parseTokenFile(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode base64
+	encrypted, err := base64.StdEncoding.DecodeString(file.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errCorruptTokenFile, err)
+	}
+
+	// Decrypt
+	decrypted, err := s.decryptFile(file, encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt token file: %w", err)
+	}
+
+	// Parse JSON
+	var store tokenStore
+	if err := json.Unmarshal(decrypted, &store); err != nil {
+		return nil, fmt.Errorf("%w: %w", errCorruptTokenFile, err)
+	}
+
+	if store.Data == nil {
+		store.Data = make(map[string]string)
+	}
+
+	// Rewrite legacy files, and machine-key files once a passphrase is required.
+	if file.Version < tokenFileVersion || (s.usePassphrase && file.KDF == kdfMachine) {
+		if s.usePassphrase && file.KDF == kdfMachine {
+			fmt.Fprintln(os.Stderr, "🔐 Protegiendo el archivo de tokens con una frase de paso...")
+		}
+		if err := s.saveStore(&store); err != nil {
+			return nil, fmt.Errorf("failed to migrate token file: %w", err)
+		}
+	}
+
+	return &store, nil
+}
+
+// discardCorruptFile moves an unreadable token file out of the way so the next login starts afresh.
+// The file is kept next to the original for inspection.
+func (s *fileStore) discardCorruptFile(cause error) (*tokenStore, error) {
+	if err := os.Rename(s.path, s.path+".corrupt"); err != nil {
+		return nil, fmt.Errorf("%w and could not be moved aside: %w", cause, err)
+	}
+	s.key = nil
+	s.keyCache.clear()
+
+	fmt.Fprintln(os.Stderr, "⚠️  El archivo de tokens estaba dañado y se ha descartado.")
+	fmt.Fprintln(os.Stderr, "💡 Ejecuta 'missions login' para iniciar sesión de nuevo")
+	return &tokenStore{Data: make(map[string]string)}, nil
+}
+
+// setAsideUnreadableFile moves a token file that can't be decrypted with the machine key out of the
+// way, so a new login can be stored. It is only used when writing, as the user is signing in again.
+func (s *fileStore) setAsideUnreadableFile(cause error) (*tokenStore, error) {
+	unreadable := s.path + ".unreadable"
+	if err := os.Rename(s.path, unreadable); err != nil {
+		return nil, fmt.Errorf("%w and could not be moved aside: %w", cause, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "⚠️  El archivo de tokens anterior no se puede descifrar en este equipo y se ha guardado en %s\n", unreadable)
+	return &tokenStore{Data: make(map[string]string)}, nil
+}
+
+// decryptFile decrypts the payload of a token file with the key its envelope asks for.
+func (s *fileStore) decryptFile(file *tokenFile, encrypted []byte) ([]byte, error) {
+	if file.KDF == kdfMachine {
+		decrypted, err := decrypt(s.getEncryptionKey(), encrypted)
+		if err != nil && !errors.Is(err, errCorruptTokenFile) {
+			return nil, errTokenKeyMismatch
+		}
+		return decrypted, err
+	}
+	if file.KDF != kdfScrypt {
+		return nil, fmt.Errorf("unsupported key derivation %q", file.KDF)
+	}
+
+	// A key cached in memory or for the terminal session avoids asking for the passphrase again.
+	if s.key == nil || !s.params.equal(file.Scrypt) {
+		if cached := s.keyCache.load(file.Scrypt); cached != nil {
+			s.key, s.params = cached, file.Scrypt
+		}
+	}
+	if s.key != nil && s.params.equal(file.Scrypt) {
+		if decrypted, err := decrypt(s.key, encrypted); err == nil {
+			return decrypted, nil
+		}
+		s.key = nil
+		s.keyCache.clear()
+	}
+
+	passphrase, err := readPassphrase("🔑 Frase de paso del archivo de tokens: ", false)
+	if err != nil {
+		return nil, err
+	}
+	key, err := file.Scrypt.deriveKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted, err := decrypt(key, encrypted)
+	if errors.Is(err, errCorruptTokenFile) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.New("incorrect passphrase")
+	}
+
+	s.key, s.params = key, file.Scrypt
+	s.keyCache.store(file.Scrypt, key)
+	return decrypted, nil
+}
+
+// saveStore saves the token store to disk. The caller must hold the file lock.
+func (s *fileStore) saveStore(store *tokenStore) error {
+	// Marshal to JSON
+	jsonData, err := json.Marshal(store)
+	if err != nil {
+		return err
+	}
+
+	file := &tokenFile{Version: tokenFileVersion, KDF: kdfMachine}
+	key := s.getEncryptionKey()
+
+	// Once a file is protected by a passphrase it stays that way, even if the option is turned off.
+	if s.usePassphrase || s.key != nil {
+		if s.key == nil {
+			if err := s.createPassphraseKey(); err != nil {
+				return err
+			}
+		}
+		file.KDF, file.Scrypt, key = kdfScrypt, s.params, s.key
+	}
+
+	// Encrypt
+	encrypted, err := encrypt(key, jsonData)
+	if err != nil {
+		return err
+	}
+
+	// Encode to base64
+	file.Data = base64.StdEncoding.EncodeToString(encrypted)
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	// Write to file with restrictive permissions, replacing the old file in a single step
+	return writeFileAtomic(s.path, data, 0600)
+}
+
+// createPassphraseKey asks for a new passphrase and derives the key of a new token file from it.
+func (s *fileStore) createPassphraseKey() error {
+	passphrase, err := readPassphrase("🔑 Elige una frase de paso para el archivo de tokens: ", true)
+	if err != nil {
+		return err
+	}
+
+	params, err := newScryptParam()
+	if err != nil {
+		return err
+	}
+	key, err := params.deriveKey(passphrase)
+	if err != nil {
+		return err
+	}
+
+	s.key, s.params = key, params
+	s.keyCache.store(params, key)
+	return nil
+}
+
+// Set stores a value in the encrypted file.
+func (s *fileStore) Set(key, value string) error {
+	lock, err := acquireFileLock(s.path)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	store, err := s.loadStore()
+	if errors.Is(err, errTokenKeyMismatch) {
+		store, err = s.setAsideUnreadableFile(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	store.Data[key] = value
+	return s.saveStore(store)
+}
+
+// Get retrieves a value from the encrypted file.
+func (s *fileStore) Get(key string) (string, error) {
+	lock, err := acquireFileLock(s.path)
+	if err != nil {
+		return "", err
+	}
+	defer lock.release()
+
+	store, err := s.loadStore()
+	if err != nil {
+		return "", err
+	}
+
+	value, exists := store.Data[key]
+	if !exists {
+		return "", ErrCredentialNotFound
+	}
+
+	return value, nil
+}
+
+// Delete removes a value from the encrypted file.
+func (s *fileStore) Delete(key string) error {
+	lock, err := acquireFileLock(s.path)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	store, err := s.loadStore()
+	if err != nil {
+		return err
+	}
+
+	delete(store.Data, key)
+
+	// Don't leave an empty token file behind once the last credential is gone.
+	if len(store.Data) == 0 {
+		return s.removeFile()
+	}
+	return s.saveStore(store)
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockTimeout bounds how long a command waits for another missions process holding the token file.
+// It is generous because the other process may be waiting for a passphrase.
+const lockTimeout = 60 * time.Second
+
+const lockRetryInterval = 50 * time.Millisecond
+
+// errLockBusy is returned by tryLockFile when another process holds the lock.
+var errLockBusy = errors.New("lock is held by another process")
+
+// fileLock is an advisory, cross-process lock on a sidecar file next to the token file.
+type fileLock struct {
+	file *os.File
+}
+
+// acquireFileLock takes an exclusive lock for path, waiting for other processes to release it.
+func acquireFileLock(path string) (*fileLock, error) {
+	lockPath := path + ".lock"
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0600) // #nosec G304 -- path is the token file location.
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	warned := false
+	for {
+		err = tryLockFile(file)
+		if err == nil {
+			return &fileLock{file: file}, nil
+		}
+		if !errors.Is(err, errLockBusy) {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock token file: %w", err)
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			return nil, fmt.Errorf("timed out waiting for another missions process to release %s", lockPath)
+		}
+		if !warned {
+			fmt.Fprintln(os.Stderr, "⏳ Esperando a que otro proceso de missions termine de usar el archivo de tokens...")
+			warned = true
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// release unlocks and closes the lock file. The file itself is kept so that every process
+// always locks the same inode.
+func (l *fileLock) release() {
+	_ = unlockFile(l.file)
+	_ = l.file.Close()
+}
+
+// writeFileAtomic replaces path with data so readers see either the old or the new content,
+// never a truncated file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		if tmpPath != "" {
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	tmpPath = ""
+	return nil
+}
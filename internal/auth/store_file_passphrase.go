@@ -0,0 +1,276 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+)
+
+// scrypt cost parameters for new token files (see RFC 7914, section 2).
+const (
+	scryptN       = 1 << 15
+	scryptR       = 8
+	scryptP       = 1
+	scryptKeyLen  = 32
+	scryptSaltLen = 16
+)
+
+// scryptParam holds the key derivation parameters stored in the token file header.
+type scryptParam struct {
+	Salt string `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+// newScryptParam creates key derivation parameters with a fresh random salt.
+func newScryptParam() (*scryptParam, error) {
+	salt := make([]byte, scryptSaltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	return &scryptParam{
+		Salt: base64.StdEncoding.EncodeToString(salt),
+		N:    scryptN,
+		R:    scryptR,
+		P:    scryptP,
+	}, nil
+}
+
+// deriveKey derives the file encryption key from a passphrase.
+func (p *scryptParam) deriveKey(passphrase string) ([]byte, error) {
+	salt, err := base64.StdEncoding.DecodeString(p.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key derivation salt: %w", err)
+	}
+	return scrypt.Key([]byte(passphrase), salt, p.N, p.R, p.P, scryptKeyLen)
+}
+
+// equal reports whether both parameter sets derive the same key from the same passphrase.
+func (p *scryptParam) equal(other *scryptParam) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return *p == *other
+}
+
+// readPassphrase returns the token file passphrase, from MISSIONS_CLI_PASSPHRASE or by asking on the terminal.
+// When confirm is true the passphrase is asked twice, as it is done when a new one is chosen.
+func readPassphrase(prompt string, confirm bool) (string, error) {
+	if passphrase := config.NewConfig().GetFilePassphrase(); passphrase != "" {
+		return passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd()) // #nosec G115 -- file descriptors fit in an int.
+	if !term.IsTerminal(fd) {
+		return "", errors.New("the token file is protected by a passphrase: set MISSIONS_CLI_PASSPHRASE " +
+			"or run the command from a terminal")
+	}
+
+	passphrase, err := promptPassword(fd, prompt)
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("the passphrase can't be empty")
+	}
+
+	if confirm {
+		repeated, err := promptPassword(fd, "   Repite la frase de paso: ")
+		if err != nil {
+			return "", err
+		}
+		if repeated != passphrase {
+			return "", errors.New("the passphrases don't match")
+		}
+	}
+
+	return passphrase, nil
+}
+
+// promptPassword reads a line from the terminal without echoing it.
+func promptPassword(fd int, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+
+	password, err := term.ReadPassword(fd)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return string(password), nil
+}
+
+// sessionKeyCache keeps the unlocked file key for a limited time, scoped to the terminal
+// session (the parent process of the CLI), so the passphrase isn't asked on every command.
+// The key is only written to a per-user directory (see sessionKeyCacheDir), and only while it is
+// owned by the user and private to them. Expired entries are removed whenever the cache is used.
+type sessionKeyCache struct {
+	path string
+	ttl  time.Duration
+}
+
+// sessionKeyFilePrefix starts the name of every session key cache file.
+const sessionKeyFilePrefix = "session-"
+
+// cachedKey is the content of a session key cache file.
+type cachedKey struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Salt      string    `json:"salt"`
+	Key       string    `json:"key"`
+}
+
+// newSessionKeyCache creates the key cache of a token file. A non-positive ttl disables caching.
+func newSessionKeyCache(tokenPath string, ttl time.Duration) *sessionKeyCache {
+	if ttl <= 0 {
+		return &sessionKeyCache{}
+	}
+
+	dir, ok := sessionKeyCacheDir()
+	if !ok {
+		return &sessionKeyCache{}
+	}
+
+	session := sha256.Sum256([]byte(tokenPath + ":" + terminalSession()))
+	const sessionIDLen = 8
+	return &sessionKeyCache{
+		path: filepath.Join(dir, sessionKeyFilePrefix+hex.EncodeToString(session[:sessionIDLen])),
+		ttl:  ttl,
+	}
+}
+
+// load returns the cached key for the given parameters, or nil if there is none or it expired.
+func (c *sessionKeyCache) load(params *scryptParam) []byte {
+	if c.path == "" || params == nil {
+		return nil
+	}
+	// A directory that is no longer private can't be trusted to hold the key we read back.
+	if !isPrivateDir(filepath.Dir(c.path)) {
+		return nil
+	}
+	c.prune()
+
+	cached, ok := readCachedKey(c.path)
+	if !ok || cached.Salt != params.Salt {
+		c.clear()
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(cached.Key)
+	if err != nil {
+		c.clear()
+		return nil
+	}
+	return key
+}
+
+// store caches a key. Failing to cache is not an error: the passphrase will simply be asked again.
+func (c *sessionKeyCache) store(params *scryptParam, key []byte) {
+	if c.path == "" {
+		return
+	}
+
+	dir := filepath.Dir(c.path)
+	if err := makePrivateDir(dir); err != nil {
+		return
+	}
+	// Never write the key into a directory other users could read or that was planted for us.
+	if !isPrivateDir(dir) {
+		return
+	}
+	c.prune()
+
+	data, err := json.Marshal(cachedKey{
+		ExpiresAt: time.Now().Add(c.ttl),
+		Salt:      params.Salt,
+		Key:       base64.StdEncoding.EncodeToString(key),
+	})
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(c.path, data, 0600)
+}
+
+// clear removes the cached key.
+func (c *sessionKeyCache) clear() {
+	if c.path == "" {
+		return
+	}
+	_ = os.Remove(c.path)
+}
+
+// prune removes the entries of every terminal session that have expired or can't be read as a
+// cached key, so keys of sessions that ended don't stay behind until the user logs out.
+func (c *sessionKeyCache) prune() {
+	dir := filepath.Dir(c.path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), sessionKeyFilePrefix) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if _, ok := readCachedKey(path); !ok {
+			_ = os.Remove(path)
+		}
+	}
+}
+
+// readCachedKey reads a session key cache file. It reports false when the file is not a regular
+// file, can't be parsed or has expired.
+func readCachedKey(path string) (*cachedKey, bool) {
+	info, err := os.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 -- the path is inside the private cache directory.
+	if err != nil {
+		return nil, false
+	}
+
+	var cached cachedKey
+	if err := json.Unmarshal(data, &cached); err != nil || !time.Now().Before(cached.ExpiresAt) {
+		return nil, false
+	}
+	return &cached, true
+}
+
+// terminalSession identifies the terminal session the CLI runs in by its parent process. Where
+// the start time of the parent is known, it is included too, so a later process that is given
+// the same PID does not share the session.
+func terminalSession() string {
+	ppid := strconv.Itoa(os.Getppid())
+
+	stat, err := os.ReadFile("/proc/" + ppid + "/stat") // #nosec G304 -- the path is built from a PID.
+	if err != nil {
+		return ppid
+	}
+	// The command name in the second field may contain spaces, so fields are counted after it.
+	// The start time is the 22nd field.
+	const startTimeField = 22 - 3
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	if len(fields) <= startTimeField {
+		return ppid
+	}
+	return ppid + ":" + fields[startTimeField]
+}
@@ -0,0 +1,44 @@
+//go:build !windows
+
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+)
+
+// sessionKeyCacheDir returns the directory for unlocked file keys. It is inside the user runtime
+// directory, which is kept in memory and removed when the user logs out, or on macOS, which has
+// none, inside the temporary directory launchd gives each user in TMPDIR. Either one is only used
+// while it is private to the user. Otherwise keys are not cached: anywhere else they would stay
+// readable on disk next to the token file they unlock.
+func sessionKeyCacheDir() (string, bool) {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" && isPrivateDir(runtimeDir) {
+		return filepath.Join(runtimeDir, "missions-cli"), true
+	}
+	if runtime.GOOS == "darwin" {
+		if tempDir := os.Getenv("TMPDIR"); tempDir != "" && isPrivateDir(tempDir) {
+			return filepath.Join(tempDir, "missions-cli"), true
+		}
+	}
+	return "", false
+}
+
+// makePrivateDir creates dir, if missing, so that only the current user can access it.
+func makePrivateDir(dir string) error {
+	return os.MkdirAll(dir, 0700)
+}
+
+// isPrivateDir reports whether dir is a directory, not a link, owned by the current user and
+// closed to everybody else.
+func isPrivateDir(dir string) bool {
+	info, err := os.Lstat(dir)
+	if err != nil || !info.IsDir() || info.Mode().Perm()&0077 != 0 {
+		return false
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int64(stat.Uid) == int64(os.Getuid())
+}
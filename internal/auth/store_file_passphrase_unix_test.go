@@ -0,0 +1,181 @@
+//go:build !windows
+
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// privateTempDir returns a temporary directory only the current user can access.
+func privateTempDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// newPrivateSessionKeyCache creates a key cache in a private runtime directory. It fails the test
+// if the cache is turned off, which would leave its entries in the working directory.
+func newPrivateSessionKeyCache(t *testing.T) *sessionKeyCache {
+	t.Helper()
+	t.Setenv("XDG_RUNTIME_DIR", privateTempDir(t))
+	cache := newSessionKeyCache(filepath.Join(t.TempDir(), ".tokens"), time.Minute)
+	if cache.path == "" {
+		t.Fatal("newSessionKeyCache disabled the cache with a private runtime dir")
+	}
+	return cache
+}
+
+func TestSessionKeyCacheDir(t *testing.T) {
+	private := privateTempDir(t)
+	open := t.TempDir()
+	if err := os.Chmod(open, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	privateTemp := privateTempDir(t)
+	// Only macOS, which has no runtime dir, falls back to the private TMPDIR of the user.
+	var darwinTemp string
+	if runtime.GOOS == "darwin" {
+		darwinTemp = privateTemp
+	}
+
+	tests := []struct {
+		name       string
+		runtimeDir string
+		tempDir    string
+		wantParent string
+	}{
+		{name: "private runtime dir", runtimeDir: private, tempDir: privateTemp, wantParent: private},
+		{name: "no runtime dir", runtimeDir: "", tempDir: open},
+		{name: "runtime dir open to others", runtimeDir: open, tempDir: open},
+		{name: "missing runtime dir", runtimeDir: filepath.Join(private, "missing"), tempDir: open},
+		{name: "private temp dir", runtimeDir: "", tempDir: privateTemp, wantParent: darwinTemp},
+		{name: "runtime dir open to others and private temp dir", runtimeDir: open, tempDir: privateTemp, wantParent: darwinTemp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_RUNTIME_DIR", tt.runtimeDir)
+			t.Setenv("TMPDIR", tt.tempDir)
+			dir, ok := sessionKeyCacheDir()
+			if ok != (tt.wantParent != "") {
+				t.Fatalf("sessionKeyCacheDir() = %q, %v, want a directory inside %q", dir, ok, tt.wantParent)
+			}
+			if ok && filepath.Dir(dir) != tt.wantParent {
+				t.Errorf("sessionKeyCacheDir() = %q, want a directory inside %q", dir, tt.wantParent)
+			}
+		})
+	}
+}
+
+func TestSessionKeyCacheStoreAndLoad(t *testing.T) {
+	cache := newPrivateSessionKeyCache(t)
+	params := &scryptParam{Salt: "c2FsdA==", N: scryptN, R: scryptR, P: scryptP}
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	cache.store(params, key)
+	if got := cache.load(params); !bytes.Equal(got, key) {
+		t.Errorf("load() = %q, want %q", got, key)
+	}
+
+	other := &scryptParam{Salt: "b3RoZXI=", N: scryptN, R: scryptR, P: scryptP}
+	if got := cache.load(other); got != nil {
+		t.Errorf("load() with another salt = %q, want nil", got)
+	}
+	if _, err := os.Stat(cache.path); !os.IsNotExist(err) {
+		t.Errorf("entry for another salt was kept: %v", err)
+	}
+}
+
+func TestSessionKeyCacheWithoutRuntimeDir(t *testing.T) {
+	if runtime.GOOS == "darwin" {
+		t.Skip("macOS caches keys in TMPDIR instead")
+	}
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	cache := newSessionKeyCache(filepath.Join(t.TempDir(), ".tokens"), time.Minute)
+	if cache.path != "" {
+		t.Errorf("newSessionKeyCache() path = %q, want caching disabled", cache.path)
+	}
+}
+
+func TestSessionKeyCachePrunesExpiredEntries(t *testing.T) {
+	cache := newPrivateSessionKeyCache(t)
+	params := &scryptParam{Salt: "c2FsdA==", N: scryptN, R: scryptR, P: scryptP}
+
+	dir := filepath.Dir(cache.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	writeEntry := func(name string, expiresAt time.Time) string {
+		t.Helper()
+		data, err := json.Marshal(cachedKey{ExpiresAt: expiresAt, Salt: params.Salt, Key: "a2V5"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	expired := writeEntry(sessionKeyFilePrefix+"ended", time.Now().Add(-time.Second))
+	live := writeEntry(sessionKeyFilePrefix+"other", time.Now().Add(time.Hour))
+	garbage := filepath.Join(dir, sessionKeyFilePrefix+"garbage")
+	if err := os.WriteFile(garbage, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	unrelated := filepath.Join(dir, "unrelated")
+	if err := os.WriteFile(unrelated, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cache.store(params, []byte("key"))
+
+	for path, wantKept := range map[string]bool{expired: false, garbage: false, live: true, unrelated: true, cache.path: true} {
+		_, err := os.Stat(path)
+		if kept := err == nil; kept != wantKept {
+			t.Errorf("%s kept = %v, want %v", filepath.Base(path), kept, wantKept)
+		}
+	}
+
+	// An expired entry of the current session is removed when it is read.
+	writeEntry(filepath.Base(cache.path), time.Now().Add(-time.Second))
+	if got := cache.load(params); got != nil {
+		t.Errorf("load() of an expired entry = %q, want nil", got)
+	}
+	if _, err := os.Stat(cache.path); !os.IsNotExist(err) {
+		t.Errorf("expired entry was kept: %v", err)
+	}
+}
+
+func TestSessionKeyCacheRefusesOpenDir(t *testing.T) {
+	cache := newPrivateSessionKeyCache(t)
+	params := &scryptParam{Salt: "c2FsdA==", N: scryptN, R: scryptR, P: scryptP}
+
+	cache.store(params, []byte("key"))
+	if err := os.Chmod(filepath.Dir(cache.path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := cache.load(params); got != nil {
+		t.Errorf("load() from a directory open to others = %q, want nil", got)
+	}
+	if err := os.Remove(cache.path); err != nil {
+		t.Fatal(err)
+	}
+	cache.store(params, []byte("key"))
+	if _, err := os.Stat(cache.path); !os.IsNotExist(err) {
+		t.Errorf("store() wrote into a directory open to others: %v", err)
+	}
+}
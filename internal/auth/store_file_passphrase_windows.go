@@ -0,0 +1,103 @@
+//go:build windows
+
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// sessionKeyCacheDir returns the directory for unlocked file keys inside the temporary directory
+// of the user, which Windows keeps in the user profile. The directory is created with an access
+// list that only lets the current user in, and that is checked before every use.
+func sessionKeyCacheDir() (string, bool) {
+	return filepath.Join(os.TempDir(), "missions-cli"), true
+}
+
+// makePrivateDir creates dir, if missing, owned by the current user and with a protected access
+// list that grants access only to them, so it doesn't inherit the permissions of its parent.
+func makePrivateDir(dir string) error {
+	if _, err := os.Lstat(dir); err == nil {
+		return nil
+	}
+
+	user, err := currentUserSID()
+	if err != nil {
+		return err
+	}
+	sd, err := windows.SecurityDescriptorFromString("O:" + user.String() + "D:P(A;OICI;FA;;;" + user.String() + ")")
+	if err != nil {
+		return err
+	}
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return err
+	}
+
+	attributes := &windows.SecurityAttributes{SecurityDescriptor: sd}
+	attributes.Length = uint32(unsafe.Sizeof(*attributes))
+	return windows.CreateDirectory(path, attributes)
+}
+
+// isPrivateDir reports whether dir is a directory, not a link, owned by the current user and
+// with a protected access list whose entries all grant access to the current user only.
+func isPrivateDir(dir string) bool {
+	info, err := os.Lstat(dir)
+	if err != nil || !info.IsDir() || info.Mode()&os.ModeSymlink != 0 {
+		return false
+	}
+
+	user, err := currentUserSID()
+	if err != nil {
+		return false
+	}
+	sd, err := windows.GetNamedSecurityInfo(dir, windows.SE_FILE_OBJECT,
+		windows.OWNER_SECURITY_INFORMATION|windows.DACL_SECURITY_INFORMATION)
+	if err != nil {
+		return false
+	}
+
+	owner, _, err := sd.Owner()
+	if err != nil || !owner.Equals(user) {
+		return false
+	}
+	control, _, err := sd.Control()
+	if err != nil || control&windows.SE_DACL_PROTECTED == 0 {
+		return false
+	}
+	dacl, _, err := sd.DACL()
+	if err != nil || dacl == nil {
+		return false
+	}
+
+	for i := uint32(0); i < uint32(dacl.AceCount); i++ {
+		var ace *windows.ACCESS_ALLOWED_ACE
+		if err := windows.GetAce(dacl, i, &ace); err != nil {
+			return false
+		}
+		if ace.Header.AceType != windows.ACCESS_ALLOWED_ACE_TYPE {
+			return false
+		}
+		sid := (*windows.SID)(unsafe.Pointer(&ace.SidStart))
+		if !sid.Equals(user) {
+			return false
+		}
+	}
+	return true
+}
+
+// currentUserSID returns the security identifier of the user running the CLI.
+func currentUserSID() (*windows.SID, error) {
+	tokenUser, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return nil, err
+	}
+	if tokenUser.User.Sid == nil {
+		return nil, errors.New("the process token has no user")
+	}
+	return tokenUser.User.Sid, nil
+}
@@ -0,0 +1,51 @@
+//go:build windows
+
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPrivateDir(t *testing.T) {
+	private := filepath.Join(t.TempDir(), "private")
+	if err := makePrivateDir(private); err != nil {
+		t.Fatalf("makePrivateDir: %v", err)
+	}
+	if !isPrivateDir(private) {
+		t.Error("a directory created by makePrivateDir is not private")
+	}
+
+	// A plain directory inherits the access list of the temporary directory.
+	inherited := filepath.Join(t.TempDir(), "inherited")
+	if err := os.Mkdir(inherited, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if isPrivateDir(inherited) {
+		t.Error("a directory with an inherited access list is private")
+	}
+	if isPrivateDir(filepath.Join(private, "missing")) {
+		t.Error("a missing directory is private")
+	}
+}
+
+func TestSessionKeyCacheStoreAndLoad(t *testing.T) {
+	t.Setenv("TMP", t.TempDir())
+	params := &scryptParam{Salt: "c2FsdA==", N: scryptN, R: scryptR, P: scryptP}
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	cache := newSessionKeyCache(filepath.Join(t.TempDir(), ".tokens"), time.Minute)
+	if cache.path == "" {
+		t.Fatal("newSessionKeyCache disabled the cache")
+	}
+
+	cache.store(params, key)
+	if got := cache.load(params); string(got) != string(key) {
+		t.Errorf("load() = %q, want %q", got, key)
+	}
+	if !isPrivateDir(filepath.Dir(cache.path)) {
+		t.Error("the cache directory is not private")
+	}
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestFileStore creates a machine-key file store in a temporary directory.
+func newTestFileStore(t *testing.T) *fileStore {
+	t.Helper()
+	t.Setenv("USER", "alice")
+	return &fileStore{
+		service:  "missions-cli-test",
+		path:     filepath.Join(t.TempDir(), ".tokens"),
+		keyCache: &sessionKeyCache{},
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	store := newTestFileStore(t)
+
+	if err := store.Set(accessTokenKey, "access"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if value, err := store.Get(accessTokenKey); err != nil || value != "access" {
+		t.Errorf("Get = %q, %v, want %q", value, err, "access")
+	}
+	if _, err := store.Get(refreshTokenKey); !errors.Is(err, ErrCredentialNotFound) {
+		t.Errorf("Get of a missing key error = %v, want ErrCredentialNotFound", err)
+	}
+	if err := store.Delete(accessTokenKey); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if fileExists(store.path) {
+		t.Error("the token file was kept after deleting its last credential")
+	}
+}
+
+func TestFileStoreKeyMismatchKeepsFile(t *testing.T) {
+	store := newTestFileStore(t)
+	if err := store.Set(accessTokenKey, "access"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	// The same file read by another user, or after the host name changed.
+	t.Setenv("USER", "bob")
+	if _, err := store.Get(accessTokenKey); !errors.Is(err, errTokenKeyMismatch) {
+		t.Fatalf("Get with another machine key error = %v, want errTokenKeyMismatch", err)
+	}
+	if !fileExists(store.path) || fileExists(store.path+".corrupt") {
+		t.Fatal("a token file encrypted with another machine key was moved aside when read")
+	}
+	if err := store.Delete(accessTokenKey); !errors.Is(err, errTokenKeyMismatch) {
+		t.Errorf("Delete with another machine key error = %v, want errTokenKeyMismatch", err)
+	}
+
+	// Once the machine key matches again the session is still there.
+	t.Setenv("USER", "alice")
+	if value, err := store.Get(accessTokenKey); err != nil || value != "access" {
+		t.Errorf("Get with the original machine key = %q, %v, want %q", value, err, "access")
+	}
+}
+
+func TestFileStoreLoginAfterKeyMismatch(t *testing.T) {
+	store := newTestFileStore(t)
+	if err := store.Set(accessTokenKey, "old"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	t.Setenv("USER", "bob")
+	if err := store.Set(accessTokenKey, "new"); err != nil {
+		t.Fatalf("Set with another machine key: %v", err)
+	}
+	if value, err := store.Get(accessTokenKey); err != nil || value != "new" {
+		t.Errorf("Get = %q, %v, want %q", value, err, "new")
+	}
+	if !fileExists(store.path + ".unreadable") {
+		t.Error("the unreadable token file was not kept aside")
+	}
+}
+
+func TestFileStoreDiscardsDamagedFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "broken envelope", content: `{"version": 2, "kdf": "machine", "data": `},
+		{name: "invalid base64", content: `{"version": 2, "kdf": "machine", "data": "%%%"}`},
+		{name: "truncated ciphertext", content: `{"version": 2, "kdf": "machine", "data": "AAAA"}`},
+		{name: "scrypt without parameters", content: `{"version": 2, "kdf": "scrypt", "data": "AAAA"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestFileStore(t)
+			if err := os.WriteFile(store.path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := store.Get(accessTokenKey); !errors.Is(err, ErrCredentialNotFound) {
+				t.Errorf("Get from a damaged file error = %v, want ErrCredentialNotFound", err)
+			}
+			if !fileExists(store.path + ".corrupt") {
+				t.Error("the damaged file was not moved aside")
+			}
+		})
+	}
+}
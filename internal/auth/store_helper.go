@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+)
+
+// helperStore delegates credential storage to an external executable, using a protocol
+// modelled after git credential helpers. The helper is run as
+//
+//	<helper> get|store|erase
+//
+// and receives the request on stdin as key=value lines terminated by a blank line:
+//
+//	service=missions-cli
+//	key=access_token
+//	value=...            (store only)
+//
+// For get, the helper prints value=<value> on stdout, or nothing when the key is unknown.
+// A non-zero exit status is reported as an error. A helper name without path separators
+// or spaces is looked up on the PATH as missions-credential-<name>.
+type helperStore struct {
+	service string
+	command []string
+}
+
+// Helper protocol operations.
+const (
+	helperOpGet   = "get"
+	helperOpStore = "store"
+	helperOpErase = "erase"
+)
+
+// newHelperStore creates a store backed by the credential helper configured in MISSIONS_CLI_CREDENTIAL_HELPER.
+func newHelperStore(service string) (CredentialStore, error) {
+	helper := strings.TrimSpace(config.NewConfig().GetCredentialHelper())
+	if helper == "" {
+		return nil, errors.New("no credential helper configured, set MISSIONS_CLI_CREDENTIAL_HELPER")
+	}
+
+	command := strings.Fields(helper)
+	if len(command) == 1 && !strings.ContainsAny(command[0], `/\`) && filepath.Ext(command[0]) == "" {
+		command[0] = "missions-credential-" + command[0]
+	}
+
+	return &helperStore{
+		service: service,
+		command: command,
+	}, nil
+}
+
+// Name returns the backend name.
+func (s *helperStore) Name() string {
+	return BackendHelper
+}
+
+// Get asks the helper for a value.
+func (s *helperStore) Get(key string) (string, error) {
+	output, err := s.run(helperOpGet, key, "")
+	if err != nil {
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if value, found := strings.CutPrefix(scanner.Text(), "value="); found {
+			return value, nil
+		}
+	}
+	return "", ErrCredentialNotFound
+}
+
+// Set asks the helper to store a value.
+func (s *helperStore) Set(key, value string) error {
+	if strings.ContainsAny(value, "\r\n") {
+		return errors.New("credential helper values can't contain line breaks")
+	}
+	_, err := s.run(helperOpStore, key, value)
+	return err
+}
+
+// Delete asks the helper to erase a value.
+func (s *helperStore) Delete(key string) error {
+	_, err := s.run(helperOpErase, key, "")
+	return err
+}
+
+// run executes the helper for an operation and returns its standard output.
+func (s *helperStore) run(operation, key, value string) ([]byte, error) {
+	var request strings.Builder
+	fmt.Fprintf(&request, "service=%s\nkey=%s\n", s.service, key)
+	if operation == helperOpStore {
+		fmt.Fprintf(&request, "value=%s\n", value)
+	}
+	request.WriteString("\n")
+
+	const helperTimeout = 30 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), helperTimeout)
+	defer cancel()
+
+	args := append(append([]string{}, s.command[1:]...), operation)
+	// #nosec G204 -- the helper is configured by the user in the environment, never by a .env file.
+	cmd := exec.CommandContext(ctx, s.command[0], args...)
+	cmd.Stdin = strings.NewReader(request.String())
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("credential helper %s %s failed: %w: %s",
+			s.command[0], operation, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/zalando/go-keyring"
+)
+
+// keyringStore keeps credentials in the operating system keyring.
+type keyringStore struct {
+	service string
+}
+
+// newKeyringStore creates a store backed by the system keyring.
+func newKeyringStore(service string) (CredentialStore, error) {
+	return &keyringStore{service: service}, nil
+}
+
+// isKeyringAvailable checks if the system keyring is available.
+func isKeyringAvailable(service string) bool {
+	// Try to set and delete a test value
+	testKey := "__test_availability__"
+	testValue := "test"
+
+	err := keyring.Set(service, testKey, testValue)
+	if err != nil {
+		return false
+	}
+
+	// Clean up test value
+	_ = keyring.Delete(service, testKey)
+	return true
+}
+
+// Name returns the backend name.
+func (s *keyringStore) Name() string {
+	return BackendKeyring
+}
+
+// Get retrieves a value from the keyring.
+func (s *keyringStore) Get(key string) (string, error) {
+	value, err := keyring.Get(s.service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrCredentialNotFound
+	}
+	return value, err
+}
+
+// Set stores a value in the keyring.
+func (s *keyringStore) Set(key, value string) error {
+	return keyring.Set(s.service, key, value)
+}
+
+// Delete removes a value from the keyring.
+func (s *keyringStore) Delete(key string) error {
+	err := keyring.Delete(s.service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return ErrCredentialNotFound
+	}
+	return err
+}
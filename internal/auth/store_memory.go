@@ -0,0 +1,62 @@
+package auth
+
+import "sync"
+
+// memoryStore keeps credentials in memory for the lifetime of the process.
+// Nothing is written to disk, which makes it useful for tests and throwaway sessions.
+type memoryStore struct {
+	mu   sync.RWMutex
+	data map[string]string
+}
+
+// newMemoryStore creates an empty in-memory store.
+func newMemoryStore(_ string) (CredentialStore, error) {
+	return &memoryStore{data: make(map[string]string)}, nil
+}
+
+// Name returns the backend name.
+func (s *memoryStore) Name() string {
+	return BackendMemory
+}
+
+// Get retrieves a value from memory.
+func (s *memoryStore) Get(key string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, exists := s.data[key]
+	if !exists {
+		return "", ErrCredentialNotFound
+	}
+	return value, nil
+}
+
+// Set stores a value in memory.
+func (s *memoryStore) Set(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data[key] = value
+	return nil
+}
+
+// Delete removes a value from memory.
+func (s *memoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.data[key]; !exists {
+		return ErrCredentialNotFound
+	}
+	delete(s.data, key)
+	return nil
+}
+
+// Clear removes every value.
+func (s *memoryStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data = make(map[string]string)
+	return nil
+}
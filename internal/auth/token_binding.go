@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+)
+
+// tokenBinding records where a stored session was obtained, so its tokens are never sent to
+// another server. URLs can come from a .env file in the current directory, which a lab
+// repository controls.
+type tokenBinding struct {
+	// Issuer is the origin of the authorization server that issued the tokens.
+	Issuer string `json:"issuer"`
+	// API is the origin of the Missions API the tokens were obtained for.
+	API string `json:"api"`
+}
+
+// newTokenBinding describes the servers cfg points at.
+func newTokenBinding(cfg *config.Config) (*tokenBinding, error) {
+	issuer, err := originOf(cfg.GetTokenURL())
+	if err != nil {
+		return nil, err
+	}
+	api, err := originOf(cfg.GetRemoteURL())
+	if err != nil {
+		return nil, err
+	}
+	return &tokenBinding{Issuer: issuer, API: api}, nil
+}
+
+// loadTokenBinding returns the binding of a stored session. Sessions saved before bindings
+// existed are bound to the explicitly configured servers, never to environment overrides.
+func loadTokenBinding(storage *profileStorage) (*tokenBinding, error) {
+	data, err := storage.Get(tokenBindingKey)
+	if isNotFound(err) || (err == nil && data == "") {
+		endpoints := config.NewConfig().ForProfile(storage.profile).ConfiguredEndpoints()
+		issuer, issuerErr := originOf(endpoints.TokenURL)
+		if issuerErr != nil {
+			return nil, issuerErr
+		}
+		api, apiErr := originOf(endpoints.RemoteURL)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		return &tokenBinding{Issuer: issuer, API: api}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var binding tokenBinding
+	if unmarshalErr := json.Unmarshal([]byte(data), &binding); unmarshalErr != nil {
+		return nil, fmt.Errorf("error parsing token binding: %w", unmarshalErr)
+	}
+	return &binding, nil
+}
+
+// saveTokenBinding stores the binding of the session in storage.
+func saveTokenBinding(storage *profileStorage, binding *tokenBinding) error {
+	data, err := json.Marshal(binding)
+	if err != nil {
+		return err
+	}
+	return storage.Set(tokenBindingKey, string(data))
+}
+
+// originOf returns the scheme, host and port of rawURL, dropping default ports.
+func originOf(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+
+	scheme := strings.ToLower(parsed.Scheme)
+	if (scheme != "https" && scheme != "http") || parsed.Host == "" {
+		return "", fmt.Errorf("invalid URL %q: expected an http or https URL", rawURL)
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	port := parsed.Port()
+	if (scheme == "https" && port == "443") || (scheme == "http" && port == "80") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+
+	return scheme + "://" + host, nil
+}
+
+// checkOrigin verifies that targetURL belongs to the expected origin.
+func checkOrigin(expected, targetURL string) error {
+	origin, err := originOf(targetURL)
+	if err != nil {
+		return NewTokenOriginError(expected, targetURL, err)
+	}
+	if origin != expected {
+		return NewTokenOriginError(expected, origin, nil)
+	}
+	return nil
+}
+
+// checkIssuerURL verifies that an authorization server endpoint belongs to the issuer of the session.
+func checkIssuerURL(storage *profileStorage, targetURL string) error {
+	binding, err := loadTokenBinding(storage)
+	if err != nil {
+		return NewTokenOriginError("", targetURL, err)
+	}
+	return checkOrigin(binding.Issuer, targetURL)
+}
+
+// GetTokenForURL returns a usable access token to be sent to targetURL. Stored sessions are only
+// sent to the API origin they were obtained for; MISSIONS_TOKEN is used as given.
+func GetTokenForURL(targetURL string) (string, error) {
+	if UsesEnvironmentToken() {
+		return GetCurrentToken()
+	}
+
+	storage := getSessionStorage()
+	if accessToken, err := storage.Get(accessTokenKey); err != nil || accessToken == "" {
+		return "", NewNotAuthenticatedError(err)
+	}
+
+	binding, err := loadTokenBinding(storage)
+	if err != nil {
+		return "", NewTokenOriginError("", targetURL, err)
+	}
+	if originErr := checkOrigin(binding.API, targetURL); originErr != nil {
+		return "", originErr
+	}
+
+	return GetCurrentToken()
+}
@@ -2,23 +2,11 @@
 package auth
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"os"
-	"path/filepath"
-	"runtime"
 	"sync"
 	"time"
 
-	"github.com/zalando/go-keyring"
-
 	"github.com/eutika/eu-missions-cli/internal/config"
 )
 
@@ -29,12 +17,12 @@ type TokenResponse struct {
 	ExpiresIn    int    `json:"expires_in"`
 }
 
-// SecureStorage provides a secure way to store credentials with automatic fallback.
+// SecureStorage provides a secure way to store credentials on top of a CredentialStore,
+// falling back to an encrypted file when the system keyring is not available.
 type SecureStorage struct {
-	service      string
-	useKeyring   bool
-	fallbackPath string
-	mu           sync.RWMutex
+	store    CredentialStore
+	fallback bool
+	mu       sync.RWMutex
 }
 
 var (
@@ -45,101 +33,41 @@ var (
 // getStorage returns a singleton instance of SecureStorage.
 func getStorage() *SecureStorage {
 	storageOnce.Do(func() {
-		storageInstance = newSecureStorage(config.NewConfig().GetKeyringService())
+		cfg := config.NewConfig()
+		storage, err := newSecureStorage(cfg.GetCredentialStore(), cfg.GetKeyringService())
+		if err != nil {
+			// Keep the CLI usable: every operation on the unavailable store reports the error.
+			storage = &SecureStorage{store: &unavailableStore{err: err}}
+		}
+		storageInstance = storage
 	})
 	return storageInstance
 }
 
-// newSecureStorage creates a new secure storage instance.
-func newSecureStorage(service string) *SecureStorage {
-	s := &SecureStorage{
-		service:    service,
-		useKeyring: true,
-	}
-
-	// Test if keyring is available
-	if !s.isKeyringAvailable() {
-		s.useKeyring = false
-		s.fallbackPath = s.getFallbackPath()
-	}
-
-	return s
-}
-
-// printSecurityWarning displays a warning when using fallback storage.
-func (s *SecureStorage) printSecurityWarning() {
-	fmt.Println()
-	fmt.Println("⚠️  Aviso de Seguridad")
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println("El keyring del sistema no está disponible en este entorno.")
-	fmt.Printf("Los tokens se guardarán cifrados en: %s\n", s.fallbackPath)
-	fmt.Println()
-	fmt.Println("💡 Para mayor seguridad, considera configurar un keyring del sistema:")
-
-	switch runtime.GOOS {
-	case "linux":
-		fmt.Println("   • Instala gnome-keyring: sudo apt-get install gnome-keyring")
-		fmt.Println("   • O usa KWallet si estás en KDE")
-		fmt.Println("   • Luego ejecuta: eval $(dbus-launch --sh-syntax)")
-	case "darwin":
-		fmt.Println("   • Verifica que Keychain esté funcionando correctamente")
-		fmt.Println("   • Si estás en SSH, puede que Keychain no esté accesible")
-	case "windows":
-		fmt.Println("   • Verifica que Credential Manager esté funcionando correctamente")
-	}
-
-	fmt.Println()
-	fmt.Println("ℹ️  Más información: https://github.com/eutika/eu-missions-cli#security")
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-}
-
-// isKeyringAvailable checks if the system keyring is available.
-func (s *SecureStorage) isKeyringAvailable() bool {
-	// Allow forcing file storage for testing purposes
-	if os.Getenv("MISSIONS_CLI_FORCE_FILE_STORAGE") == "true" {
-		return false
-	}
-
-	// Try to set and delete a test value
-	testKey := "__test_availability__"
-	testValue := "test"
-
-	err := keyring.Set(s.service, testKey, testValue)
+// newSecureStorage creates a new secure storage instance on top of the named credential store.
+func newSecureStorage(storeName, service string) (*SecureStorage, error) {
+	store, fallback, err := selectCredentialStore(storeName, service)
 	if err != nil {
-		return false
+		return nil, err
 	}
 
-	// Clean up test value
-	_ = keyring.Delete(s.service, testKey)
-	return true
+	return &SecureStorage{
+		store:    store,
+		fallback: fallback,
+	}, nil
 }
 
-// getFallbackPath returns the path for the fallback storage file.
-func (s *SecureStorage) getFallbackPath() string {
-	var baseDir string
-
-	if runtime.GOOS == "windows" {
-		baseDir = os.Getenv("APPDATA")
-		if baseDir == "" {
-			baseDir = filepath.Join(os.Getenv("USERPROFILE"), "AppData", "Roaming")
-		}
-	} else {
-		// Unix-like systems (Linux, macOS)
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			homeDir = os.Getenv("HOME")
-		}
-		baseDir = filepath.Join(homeDir, ".config")
-	}
+// Backend returns the name of the credential store in use.
+func (s *SecureStorage) Backend() string {
+	return s.store.Name()
+}
 
-	configDir := filepath.Join(baseDir, "missions-cli")
-	// Ensure directory exists
-	if err := os.MkdirAll(configDir, 0700); err != nil {
-		// If we can't create the directory, fall back to temp
-		return filepath.Join(os.TempDir(), ".missions-cli-tokens")
+// FallbackPath returns the path of the encrypted token file, or an empty string when another store is used.
+func (s *SecureStorage) FallbackPath() string {
+	if file, ok := s.store.(*fileStore); ok {
+		return file.Path()
 	}
-
-	return filepath.Join(configDir, ".tokens")
+	return ""
 }
 
 // Set stores a key-value pair securely.
@@ -147,10 +75,7 @@ func (s *SecureStorage) Set(key, value string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.useKeyring {
-		return keyring.Set(s.service, key, value)
-	}
-	return s.setFallback(key, value)
+	return s.store.Set(key, value)
 }
 
 // Get retrieves a value by key.
@@ -158,10 +83,7 @@ func (s *SecureStorage) Get(key string) (string, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if s.useKeyring {
-		return keyring.Get(s.service, key)
-	}
-	return s.getFallback(key)
+	return s.store.Get(key)
 }
 
 // Delete removes a key-value pair.
@@ -169,254 +91,255 @@ func (s *SecureStorage) Delete(key string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.useKeyring {
-		return keyring.Delete(s.service, key)
-	}
-	return s.deleteFallback(key)
+	return s.store.Delete(key)
 }
 
-// Fallback storage implementation using encrypted file
+// Clear removes every credential managed by this storage. Stores that can't be enumerated,
+// like the system keyring, only get the given keys removed.
+func (s *SecureStorage) Clear(keys []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-type tokenStore struct {
-	Data map[string]string `json:"data"`
-}
+	if store, ok := s.store.(clearableStore); ok {
+		return store.Clear()
+	}
 
-// getEncryptionKey derives an encryption key from machine-specific data.
-func (s *SecureStorage) getEncryptionKey() []byte {
-	// Use hostname and username as seed for encryption key
-	hostname, _ := os.Hostname()
-	username := os.Getenv("USER")
-	if username == "" {
-		username = os.Getenv("USERNAME")
+	for _, key := range keys {
+		if err := s.store.Delete(key); err != nil && !isNotFound(err) {
+			return err
+		}
 	}
+	return nil
+}
 
-	// Create a deterministic key based on machine identity
-	seed := fmt.Sprintf("%s:%s:%s", s.service, hostname, username)
-	hash := sha256.Sum256([]byte(seed))
-	return hash[:]
+// isNotFound reports whether err means that the key does not exist in the storage.
+func isNotFound(err error) bool {
+	return errors.Is(err, ErrCredentialNotFound)
 }
 
-// encrypt encrypts data using AES-GCM.
-func (s *SecureStorage) encrypt(plaintext []byte) ([]byte, error) {
-	key := s.getEncryptionKey()
+// unavailableStore stands in for a credential store that could not be created.
+type unavailableStore struct {
+	err error
+}
 
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, err
-	}
+func (s *unavailableStore) Name() string                 { return "unavailable" }
+func (s *unavailableStore) Get(_ string) (string, error) { return "", s.err }
+func (s *unavailableStore) Set(_, _ string) error        { return s.err }
+func (s *unavailableStore) Delete(_ string) error        { return s.err }
+
+// Storage keys used to persist the session.
+const (
+	accessTokenKey    = "access_token"
+	refreshTokenKey   = "refresh_token"
+	tokenExpiresAtKey = "token_expires_at"
+	tokenBindingKey   = "token_binding"
+)
 
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, err
-	}
+// tokenKeys lists every key written by SaveTokens.
+var tokenKeys = []string{accessTokenKey, refreshTokenKey, tokenExpiresAtKey, tokenBindingKey}
 
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return nil, err
+// SaveTokens stores a freshly obtained token pair and warns when the fallback storage is in use.
+func SaveTokens(token *TokenResponse) error {
+	storage := getSessionStorage()
+	binding, err := newTokenBinding(config.NewConfig())
+	if err != nil {
+		return NewTokenSavingError(err)
 	}
 
-	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
-	return ciphertext, nil
-}
-
-// decrypt decrypts data using AES-GCM.
-func (s *SecureStorage) decrypt(ciphertext []byte) ([]byte, error) {
-	key := s.getEncryptionKey()
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, err
+	if err := storeTokens(storage, token); err != nil {
+		return err
 	}
 
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, err
+	// Bind the session to the servers it was obtained from.
+	if err := saveTokenBinding(storage, binding); err != nil {
+		return NewTokenSavingError(fmt.Errorf("error saving token binding: %w", err))
 	}
 
-	nonceSize := gcm.NonceSize()
-	if len(ciphertext) < nonceSize {
-		return nil, errors.New("ciphertext too short")
+	// Remember the profile so it shows up in 'missions profile list'.
+	if err := config.EnsureProfile(storage.profile); err != nil {
+		return NewTokenSavingError(fmt.Errorf("error registering profile %s: %w", storage.profile, err))
 	}
 
-	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return nil, err
+	// Show security warning if using fallback storage
+	if file, ok := storage.storage.store.(*fileStore); ok && storage.storage.fallback {
+		file.printSecurityWarning()
 	}
 
-	return plaintext, nil
+	return nil
 }
 
-// loadStore loads the token store from disk.
-func (s *SecureStorage) loadStore() (*tokenStore, error) {
-	data, err := os.ReadFile(s.fallbackPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return &tokenStore{Data: make(map[string]string)}, nil
-		}
-		return nil, err
+// storeTokens persists the access token, refresh token and expiration, rolling back on failure.
+func storeTokens(storage *profileStorage, token *TokenResponse) error {
+	// Save access token.
+	if err := storage.Set(accessTokenKey, token.AccessToken); err != nil {
+		return NewTokenSavingError(fmt.Errorf("error saving access token: %w", err))
 	}
 
-	// Decode base64
-	encrypted, err := base64.StdEncoding.DecodeString(string(data))
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode token file: %w", err)
+	// Save refresh token.
+	if err := storage.Set(refreshTokenKey, token.RefreshToken); err != nil {
+		if delErr := storage.Delete(accessTokenKey); delErr != nil {
+			return NewTokenSavingError(fmt.Errorf("error deleting access token: %w", delErr))
+		}
+		return NewTokenSavingError(fmt.Errorf("error saving refresh token: %w", err))
 	}
 
-	// Decrypt
-	decrypted, err := s.decrypt(encrypted)
+	// Save token expiration. Tokens without a known lifetime are used until the server rejects them.
+	var err error
+	if token.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+		err = storage.Set(tokenExpiresAtKey, expiresAt.Format(time.RFC3339))
+	} else if err = storage.Delete(tokenExpiresAtKey); isNotFound(err) {
+		err = nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt token file: %w", err)
+		if delErr := storage.Delete(accessTokenKey); delErr != nil {
+			return NewTokenSavingError(fmt.Errorf("error deleting access token: %w", delErr))
+		}
+		if delErr := storage.Delete(refreshTokenKey); delErr != nil {
+			return NewTokenSavingError(fmt.Errorf("error deleting refresh token: %w", delErr))
+		}
+		return NewTokenSavingError(fmt.Errorf("error saving token expiration: %w", err))
 	}
 
-	// Parse JSON
-	var store tokenStore
-	if err := json.Unmarshal(decrypted, &store); err != nil {
-		return nil, fmt.Errorf("failed to parse token file: %w", err)
-	}
+	return nil
+}
 
-	if store.Data == nil {
-		store.Data = make(map[string]string)
-	}
+// HasProfileSession reports whether an access token is stored for the given profile.
+func HasProfileSession(profile string) bool {
+	token, err := getProfileStorage(profile).Get(accessTokenKey)
+	return err == nil && token != ""
+}
 
-	return &store, nil
+// DeleteTokens removes every stored credential of the active profile.
+func DeleteTokens() error {
+	return DeleteProfileTokens(config.ActiveProfile())
 }
 
-// saveStore saves the token store to disk.
-func (s *SecureStorage) saveStore(store *tokenStore) error {
-	// Marshal to JSON
-	jsonData, err := json.Marshal(store)
-	if err != nil {
-		return err
+// DeleteProfileTokens removes every stored credential of the given profile.
+func DeleteProfileTokens(profile string) error {
+	storage := getProfileStorage(profile)
+	for _, key := range tokenKeys {
+		if err := storage.Delete(key); err != nil && !isNotFound(err) {
+			return NewTokenDeletingError(fmt.Errorf("error deleting %s: %w", key, err))
+		}
 	}
+	return nil
+}
 
-	// Encrypt
-	encrypted, err := s.encrypt(jsonData)
+// DeleteAllTokens removes every credential stored by the CLI, for every profile.
+func DeleteAllTokens() error {
+	profiles, err := config.LoadProfiles()
 	if err != nil {
-		return err
+		return NewTokenDeletingError(err)
 	}
 
-	// Encode to base64
-	encoded := base64.StdEncoding.EncodeToString(encrypted)
-
-	// Write to file with restrictive permissions
-	return os.WriteFile(s.fallbackPath, []byte(encoded), 0600)
-}
-
-// setFallback stores a value in the fallback storage.
-func (s *SecureStorage) setFallback(key, value string) error {
-	store, err := s.loadStore()
-	if err != nil {
-		return err
+	names := append(profiles.Names(), config.ActiveProfile())
+	keys := make([]string, 0, len(names)*len(tokenKeys))
+	for _, profile := range names {
+		for _, key := range tokenKeys {
+			keys = append(keys, profileKey(profile, key))
+		}
 	}
 
-	store.Data[key] = value
-	return s.saveStore(store)
+	if err := getStorage().Clear(keys); err != nil {
+		return NewTokenDeletingError(err)
+	}
+	return nil
 }
 
-// getFallback retrieves a value from the fallback storage.
-func (s *SecureStorage) getFallback(key string) (string, error) {
-	store, err := s.loadStore()
-	if err != nil {
-		return "", err
-	}
+const tokenExpiryBufferMinutes = 5
 
-	value, exists := store.Data[key]
-	if !exists {
-		return "", errors.New("key not found")
+// tokenExpiresAt returns the stored expiration time of the access token.
+func tokenExpiresAt() (time.Time, error) {
+	storage := getSessionStorage()
+	expiresAtStr, err := storage.Get(tokenExpiresAtKey)
+	if err != nil {
+		return time.Time{}, err
 	}
 
-	return value, nil
+	return time.Parse(time.RFC3339, expiresAtStr)
 }
 
-// deleteFallback removes a value from the fallback storage.
-func (s *SecureStorage) deleteFallback(key string) error {
-	store, err := s.loadStore()
+func IsTokenExpired() (bool, error) {
+	expiresAt, err := tokenExpiresAt()
+	if isNotFound(err) {
+		return false, nil // Token sin caducidad conocida: se usa hasta que el servidor lo rechace
+	}
 	if err != nil {
-		return err
+		return true, err // Si no podemos obtener o parsear la fecha, asumimos que expiró
 	}
 
-	delete(store.Data, key)
-	return s.saveStore(store)
+	// Considerar el token expirado tokenExpiryBufferMinutes minutos antes de su expiración real
+	return time.Now().Add(tokenExpiryBufferMinutes * time.Minute).After(expiresAt), nil
 }
 
-func SaveTokens(token *TokenResponse) error {
-	storage := getStorage()
+// GetCurrentToken returns a usable access token, refreshing the session when it is about to expire.
+// A token given through MISSIONS_TOKEN takes precedence over the stored session and is never persisted.
+func GetCurrentToken() (string, error) {
+	if token := config.NewConfig().GetEnvironmentToken(); token != "" {
+		return token, nil
+	}
 
-	// Save access token.
-	if err := storage.Set("access_token", token.AccessToken); err != nil {
-		return NewTokenSavingError(fmt.Errorf("error saving access token: %w", err))
+	storage := getSessionStorage()
+	accessToken, err := storage.Get(accessTokenKey)
+	if err != nil || accessToken == "" {
+		return "", NewNotAuthenticatedError(err)
 	}
 
-	// Save refresh token.
-	if err := storage.Set("refresh_token", token.RefreshToken); err != nil {
-		if delErr := storage.Delete("access_token"); delErr != nil {
-			return NewTokenSavingError(fmt.Errorf("error deleting access token: %w", delErr))
-		}
-		return NewTokenSavingError(fmt.Errorf("error saving refresh token: %w", err))
+	expired, err := IsTokenExpired()
+	if err == nil && !expired {
+		return accessToken, nil
 	}
 
-	// Save token expiration.
-	expiresAt := time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
-	if err := storage.Set("token_expires_at", expiresAt.Format(time.RFC3339)); err != nil {
-		if delErr := storage.Delete("access_token"); delErr != nil {
-			return NewTokenSavingError(fmt.Errorf("error deleting access token: %w", delErr))
+	if refreshErr := RefreshToken(); refreshErr != nil {
+		// A transient refresh failure is not a reason to drop a token that is still inside
+		// the expiry buffer; the next command will try to refresh it again.
+		if expiresAt, expErr := tokenExpiresAt(); expErr == nil && !IsLoginRequired(refreshErr) &&
+			time.Now().Before(expiresAt) {
+			return accessToken, nil
 		}
-		if delErr := storage.Delete("refresh_token"); delErr != nil {
-			return NewTokenSavingError(fmt.Errorf("error deleting refresh token: %w", delErr))
-		}
-		return NewTokenSavingError(fmt.Errorf("error saving token expiration: %w", err))
+		return "", refreshErr
 	}
 
-	// Show security warning if using fallback storage
-	if !storage.useKeyring {
-		storage.printSecurityWarning()
+	refreshed, err := storage.Get(accessTokenKey)
+	if err != nil {
+		return "", NewTokenRefreshError(fmt.Errorf("error reading refreshed access token: %w", err))
 	}
-
-	return nil
+	return refreshed, nil
 }
 
-const tokenExpiryBufferMinutes = 5
+// UsesEnvironmentToken reports whether the access token comes from MISSIONS_TOKEN instead of the stored session.
+func UsesEnvironmentToken() bool {
+	return config.NewConfig().GetEnvironmentToken() != ""
+}
 
-func IsTokenExpired() (bool, error) {
-	storage := getStorage()
-	expiresAtStr, err := storage.Get("token_expires_at")
-	if err != nil {
-		return true, err // Si no podemos obtener la fecha, asumimos que expiró
+// RefreshToken renews the session using the stored refresh token and persists the new token pair.
+func RefreshToken() error {
+	storage := getSessionStorage()
+	refreshToken, err := storage.Get(refreshTokenKey)
+	if err != nil || refreshToken == "" {
+		return NewSessionExpiredError(errors.New("no refresh token found"))
 	}
 
-	expiresAt, err := time.Parse(time.RFC3339, expiresAtStr)
-	if err != nil {
-		return true, err // Si no podemos parsear la fecha, asumimos que expiró
+	// The refresh token only goes back to the server that issued it.
+	if originErr := checkIssuerURL(storage, config.NewConfig().GetTokenURL()); originErr != nil {
+		return originErr
 	}
 
-	// Considerar el token expirado tokenExpiryBufferMinutes minutos antes de su expiración real
-	return time.Now().Add(tokenExpiryBufferMinutes * time.Minute).After(expiresAt), nil
-}
-
-func GetCurrentToken() (string, error) {
-	expired, err := IsTokenExpired()
+	token, err := requestTokenRefresh(refreshToken)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	if expired {
-		if refreshErr := RefreshToken(); refreshErr != nil {
-			return "", err
-		}
+	// Servers that rotate refresh tokens return a new one with every refresh and invalidate
+	// the old one; servers that do not rotate omit it, so the current one stays in use.
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
 	}
 
-	storage := getStorage()
-	return storage.Get("access_token")
-}
-
-func RefreshToken() error {
-	storage := getStorage()
-	_, err := storage.Get("refresh_token")
-	if err != nil {
-		return NewTokenRefreshError(errors.New("no refresh token found, please login again"))
+	if saveErr := storeTokens(storage, token); saveErr != nil {
+		return saveErr
 	}
 
-	// TODO: Implement refresh token login. For now, we force a new login.
-	return NewTokenRefreshError(errors.New("session expired, please login again"))
+	return nil
 }
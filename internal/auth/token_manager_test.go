@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// refreshEndpoint is a stand-in token endpoint that answers refresh requests with a fixed reply.
+type refreshEndpoint struct {
+	mu       sync.Mutex
+	requests []map[string]string
+}
+
+func newRefreshEndpoint(t *testing.T, status int, body string) (*refreshEndpoint, string) {
+	t.Helper()
+	endpoint := &refreshEndpoint{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("refresh body: %v", err)
+		}
+		endpoint.mu.Lock()
+		endpoint.requests = append(endpoint.requests, payload)
+		endpoint.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("MISSIONS_CLI_TOKEN_URL", server.URL+"/token")
+	return endpoint, server.URL
+}
+
+func (e *refreshEndpoint) received() []map[string]string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]map[string]string(nil), e.requests...)
+}
+
+// failingSetStore is a memory store that refuses to save once fail is set.
+type failingSetStore struct {
+	*memoryStore
+	fail bool
+}
+
+func (s *failingSetStore) Set(key, value string) error {
+	if s.fail {
+		return errors.New("disk full")
+	}
+	return s.memoryStore.Set(key, value)
+}
+
+func storedToken(t *testing.T, key string) string {
+	t.Helper()
+	value, err := getSessionStorage().Get(key)
+	if err != nil {
+		t.Fatalf("reading %s: %v", key, err)
+	}
+	return value
+}
+
+func TestRefreshTokenStoresNewPair(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantRefresh string
+	}{
+		{
+			name:        "rotated refresh token",
+			body:        `{"access_token":"new-access","refresh_token":"new-refresh","expires_in":3600}`,
+			wantRefresh: "new-refresh",
+		},
+		{
+			name:        "refresh token not rotated",
+			body:        `{"access_token":"new-access","expires_in":3600}`,
+			wantRefresh: "refresh",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestStorage(t, newMemoryTestStore())
+			endpoint, issuer := newRefreshEndpoint(t, http.StatusOK, tt.body)
+			storeTestSession(t, issuer)
+
+			if err := RefreshToken(); err != nil {
+				t.Fatalf("RefreshToken: %v", err)
+			}
+
+			requests := endpoint.received()
+			if len(requests) != 1 {
+				t.Fatalf("refresh requests = %d, want 1", len(requests))
+			}
+			if requests[0]["grant_type"] != "refresh_token" || requests[0]["refresh_token"] != "refresh" {
+				t.Errorf("refresh request = %v, want the stored refresh token", requests[0])
+			}
+			if got := storedToken(t, accessTokenKey); got != "new-access" {
+				t.Errorf("access token = %q, want %q", got, "new-access")
+			}
+			if got := storedToken(t, refreshTokenKey); got != tt.wantRefresh {
+				t.Errorf("refresh token = %q, want %q", got, tt.wantRefresh)
+			}
+			if expired, err := IsTokenExpired(); err != nil || expired {
+				t.Errorf("IsTokenExpired = %v, %v, want a fresh token", expired, err)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantCode string
+	}{
+		{"invalid grant", http.StatusBadRequest, `{"error":"invalid_grant"}`, ErrSessionExpired},
+		{"other OAuth error", http.StatusBadRequest, `{"error":"invalid_client"}`, ErrTokenRefresh},
+		{"server error", http.StatusInternalServerError, `oops`, ErrTokenRefresh},
+		{"no access token", http.StatusOK, `{"refresh_token":"new-refresh"}`, ErrTokenRefresh},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestStorage(t, newMemoryTestStore())
+			_, issuer := newRefreshEndpoint(t, tt.status, tt.body)
+			storeTestSession(t, issuer)
+
+			err := RefreshToken()
+			var authErr *AuthenticationError
+			if !errors.As(err, &authErr) || authErr.Code != tt.wantCode {
+				t.Fatalf("RefreshToken = %v, want code %s", err, tt.wantCode)
+			}
+			if got := IsLoginRequired(err); got != (tt.wantCode == ErrSessionExpired) {
+				t.Errorf("IsLoginRequired = %v", got)
+			}
+			if got := storedToken(t, refreshTokenKey); got != "refresh" {
+				t.Errorf("refresh token = %q, want the stored one kept", got)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenSaveError(t *testing.T) {
+	store := &failingSetStore{memoryStore: newMemoryTestStore()}
+	useTestStorage(t, store)
+	_, issuer := newRefreshEndpoint(t, http.StatusOK, `{"access_token":"new-access","refresh_token":"new-refresh"}`)
+	storeTestSession(t, issuer)
+	store.fail = true
+
+	err := RefreshToken()
+	var authErr *AuthenticationError
+	if !errors.As(err, &authErr) || authErr.Code != ErrSaveToken {
+		t.Fatalf("RefreshToken = %v, want code %s", err, ErrSaveToken)
+	}
+	var inner *AuthenticationError
+	if errors.As(authErr.Err, &inner) {
+		t.Errorf("RefreshToken = %v, want the save error without another authentication error around it", err)
+	}
+}
+
+func TestRefreshTokenOnAnotherServer(t *testing.T) {
+	useTestStorage(t, newMemoryTestStore())
+	endpoint, _ := newRefreshEndpoint(t, http.StatusOK, `{"access_token":"new-access"}`)
+	storeTestSession(t, "https://missions.eutika.com")
+
+	err := RefreshToken()
+	if !IsTokenOriginMismatch(err) {
+		t.Fatalf("RefreshToken = %v, want a token origin error", err)
+	}
+	if requests := endpoint.received(); len(requests) != 0 {
+		t.Errorf("the refresh token was sent to another server: %v", requests)
+	}
+}
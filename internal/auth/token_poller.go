@@ -5,84 +5,153 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/eutika/eu-missions-cli/internal/config"
+	"github.com/eutika/eu-missions-cli/internal/transport"
 )
 
-func PollForToken(deviceCode *DeviceCodeResponse) (*TokenResponse, error) {
+// Polling parameters from RFC 8628, section 3.5, in units of pollingIntervalUnit.
+const (
+	defaultPollingInterval = 5
+	slowDownIncrement      = 5
+)
+
+// pollingIntervalUnit is the unit of the polling intervals, seconds as in RFC 8628. Tests shorten it.
+var pollingIntervalUnit = time.Second
+
+// pollRequestTimeout bounds each token request, so a request that hangs is retried at the next
+// interval instead of using up the time left to approve the code. The timeouts configured for the
+// HTTP client still apply. Tests shorten it.
+var pollRequestTimeout = 10 * time.Second
+
+// errTokenRequestRejected is returned for client errors from the token endpoint without an OAuth
+// error code. Retrying them would only get the same answer, so polling stops.
+var errTokenRequestRejected = errors.New("token request rejected")
+
+// maxPollingFailures is how many consecutive transient errors are tolerated before giving up.
+const maxPollingFailures = 5
+
+// PollForToken polls the token endpoint until the user approves the device code, the code
+// expires or ctx is cancelled.
+func PollForToken(ctx context.Context, deviceCode *DeviceCodeResponse) (*TokenResponse, error) {
 	payload := map[string]string{
 		"client_id":   config.NewConfig().GetClientID(),
 		"device_code": deviceCode.DeviceCode,
 		"grant_type":  "urn:ietf:params:oauth:grant-type:device_code",
 	}
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, NewTokenPollingError(fmt.Errorf("error marshaling token request: %w", err))
+	}
+
+	interval := time.Duration(deviceCode.Interval) * pollingIntervalUnit
+	if interval <= 0 {
+		interval = defaultPollingInterval * pollingIntervalUnit
+	}
 
 	deadline := time.Now().Add(time.Duration(deviceCode.ExpiresIn) * time.Second)
-	interval := time.Duration(deviceCode.Interval) * time.Second
+	pollCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
 
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	client, err := transport.NewClient(config.NewConfig())
+	if err != nil {
+		return nil, NewTokenPollingError(fmt.Errorf("error configuring HTTP client: %w", err))
+	}
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
 
+	failures := 0
 	for {
-		if time.Now().After(deadline) {
-			return nil, NewTokenPollingError(errors.New("tiempo de espera por la autenticación agotado"))
-		}
-		<-ticker.C
-		jsonData, err := json.Marshal(payload)
-		if err != nil {
-			return nil, NewTokenPollingError(errors.New("error marshaling token request"))
+		select {
+		case <-pollCtx.Done():
+			if ctx.Err() != nil {
+				return nil, NewLoginCancelledError(ctx.Err())
+			}
+			return nil, NewDeviceCodeExpiredError()
+		case <-timer.C:
 		}
 
-		ctx := context.Background()
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost,
-			config.NewConfig().GetTokenURL(), bytes.NewBuffer(jsonData))
+		token, oauthError, err := requestDeviceToken(pollCtx, client, jsonData)
 		if err != nil {
-			return nil, NewTokenPollingError(errors.New("error creating token request"))
+			if pollCtx.Err() != nil {
+				timer.Reset(0)
+				continue
+			}
+			if errors.Is(err, errTokenRequestRejected) {
+				return nil, NewUnexpectedTokenResponseError(err)
+			}
+			// Network hiccups and server errors are retried at the same pace instead of aborting the login.
+			failures++
+			if failures >= maxPollingFailures {
+				return nil, NewTokenPollingError(err)
+			}
+			timer.Reset(interval)
+			continue
 		}
-		req.Header.Set("Content-Type", "application/json")
+		failures = 0
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			return nil, NewTokenPollingError(errors.New("error requesting token"))
+		switch oauthError {
+		case "":
+			return token, nil
+		case "authorization_pending":
+			// Continue polling.
+		case "slow_down":
+			// Keep the increased interval for the rest of the polling.
+			interval += slowDownIncrement * pollingIntervalUnit
+		case "access_denied":
+			return nil, NewAccessDeniedError()
+		case "expired_token":
+			return nil, NewDeviceCodeExpiredError()
+		default:
+			return nil, NewUnexpectedTokenResponseError(fmt.Errorf("token endpoint returned error %q", oauthError))
 		}
-		defer resp.Body.Close()
+		timer.Reset(interval)
+	}
+}
 
-		var errorResponse struct {
-			Error string `json:"error"`
-		}
+// requestDeviceToken performs a single token request. It returns either the token, the OAuth
+// error code sent by the server, or an error. Errors are worth retrying, except client errors
+// other than 429 Too Many Requests, which wrap errTokenRequestRejected.
+func requestDeviceToken(ctx context.Context, client *http.Client, jsonData []byte) (*TokenResponse, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, pollRequestTimeout)
+	defer cancel()
 
-		switch resp.StatusCode {
-		case http.StatusOK:
-			var token TokenResponse
-			if decodeErr := json.NewDecoder(resp.Body).Decode(&token); decodeErr != nil {
-				return nil, NewTokenPollingError(errors.New("error decoding token response"))
-			}
-			return &token, nil
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		config.NewConfig().GetTokenURL(), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, "", fmt.Errorf("error creating token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
 
-		case http.StatusBadRequest:
-			if decodeErr := json.NewDecoder(resp.Body).Decode(&errorResponse); decodeErr != nil {
-				return nil, NewTokenPollingError(errors.New("error decoding error response"))
-			}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("error requesting token: %w", err)
+	}
+	defer resp.Body.Close()
 
-			switch errorResponse.Error {
-			case "authorization_pending":
-				// Continue poolling.
-				continue
-			case "slow_down":
-				// Increase interval.
-				interval *= 2
-				ticker.Reset(interval)
-				continue
-			case "expired_token":
-				return nil, NewTokenPollingError(errors.New("el código de autorización ha expirado"))
-			default:
-				return nil, NewTokenPollingError(errors.New("error inesperado recuperando el token"))
-			}
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		var token TokenResponse
+		if decodeErr := json.NewDecoder(resp.Body).Decode(&token); decodeErr != nil || token.AccessToken == "" {
+			return nil, "unexpected_response", nil
+		}
+		return &token, "", nil
 
-		default:
-			return nil, NewTokenPollingError(errors.New("respuesta inesperada del servidor"))
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return nil, "", fmt.Errorf("token request failed with status: %d", resp.StatusCode)
+
+	default:
+		var errorResponse oauthErrorResponse
+		if decodeErr := json.NewDecoder(resp.Body).Decode(&errorResponse); decodeErr != nil || errorResponse.Error == "" {
+			if resp.StatusCode >= http.StatusBadRequest && resp.StatusCode != http.StatusTooManyRequests {
+				return nil, "", fmt.Errorf("%w with status %d", errTokenRequestRejected, resp.StatusCode)
+			}
+			return nil, "", fmt.Errorf("error decoding token error response with status %d", resp.StatusCode)
 		}
+		return nil, errorResponse.Error, nil
 	}
 }
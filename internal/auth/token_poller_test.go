@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tokenReply is one answer of the stand-in token endpoint.
+type tokenReply struct {
+	status int
+	body   string
+}
+
+var (
+	pendingReply = tokenReply{http.StatusBadRequest, `{"error":"authorization_pending"}`}
+	tokenIssued  = tokenReply{http.StatusOK, `{"access_token":"access","refresh_token":"refresh","expires_in":3600}`}
+)
+
+// tokenEndpoint serves the given replies in order, repeating the last one, and records when each request arrived.
+type tokenEndpoint struct {
+	mu       sync.Mutex
+	replies  []tokenReply
+	requests []time.Time
+	// hangFirst delays the reply to the first request, unless the client gives up on it first.
+	hangFirst time.Duration
+}
+
+func newTokenEndpoint(t *testing.T, replies ...tokenReply) *tokenEndpoint {
+	t.Helper()
+	endpoint := &tokenEndpoint{replies: replies}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["device_code"] != "device" {
+			t.Errorf("token request body = %v, %v, want the device code", body, err)
+		}
+
+		endpoint.mu.Lock()
+		first := len(endpoint.requests) == 0
+		reply := endpoint.replies[min(len(endpoint.requests), len(endpoint.replies)-1)]
+		endpoint.requests = append(endpoint.requests, time.Now())
+		hang := endpoint.hangFirst
+		endpoint.mu.Unlock()
+
+		if first && hang > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(hang):
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(reply.status)
+		_, _ = w.Write([]byte(reply.body))
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("MISSIONS_CLI_TOKEN_URL", server.URL)
+	return endpoint
+}
+
+func (e *tokenEndpoint) requestTimes() []time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]time.Time(nil), e.requests...)
+}
+
+// shortenPollingIntervals makes every polling interval unit last a millisecond.
+func shortenPollingIntervals(t *testing.T) {
+	t.Helper()
+	unit := pollingIntervalUnit
+	pollingIntervalUnit = time.Millisecond
+	t.Cleanup(func() { pollingIntervalUnit = unit })
+}
+
+func pollForTestToken(t *testing.T, interval int) (*TokenResponse, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return PollForToken(ctx, &DeviceCodeResponse{DeviceCode: "device", ExpiresIn: 60, Interval: interval})
+}
+
+func TestPollForTokenOutcomes(t *testing.T) {
+	tests := []struct {
+		name         string
+		replies      []tokenReply
+		wantCode     string
+		wantRequests int
+	}{
+		{
+			name:         "approved after pending",
+			replies:      []tokenReply{pendingReply, pendingReply, tokenIssued},
+			wantRequests: 3,
+		},
+		{
+			name:         "expired token",
+			replies:      []tokenReply{pendingReply, {http.StatusBadRequest, `{"error":"expired_token"}`}},
+			wantCode:     ErrDeviceCodeExpired,
+			wantRequests: 2,
+		},
+		{
+			name:         "access denied",
+			replies:      []tokenReply{{http.StatusBadRequest, `{"error":"access_denied"}`}},
+			wantCode:     ErrAccessDenied,
+			wantRequests: 1,
+		},
+		{
+			name:         "client error without OAuth body is terminal",
+			replies:      []tokenReply{{http.StatusForbidden, `<html>Forbidden</html>`}, tokenIssued},
+			wantCode:     ErrUnexpectedToken,
+			wantRequests: 1,
+		},
+		{
+			name:         "not found is terminal",
+			replies:      []tokenReply{{http.StatusNotFound, ``}, tokenIssued},
+			wantCode:     ErrUnexpectedToken,
+			wantRequests: 1,
+		},
+		{
+			name:         "too many requests is retried",
+			replies:      []tokenReply{{http.StatusTooManyRequests, ``}, tokenIssued},
+			wantRequests: 2,
+		},
+		{
+			name:         "server errors are retried",
+			replies:      []tokenReply{{http.StatusBadGateway, ``}, {http.StatusServiceUnavailable, ``}, tokenIssued},
+			wantRequests: 3,
+		},
+		{
+			name:         "persistent server errors give up",
+			replies:      []tokenReply{{http.StatusInternalServerError, ``}},
+			wantCode:     ErrAuthPolling,
+			wantRequests: maxPollingFailures,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shortenPollingIntervals(t)
+			endpoint := newTokenEndpoint(t, tt.replies...)
+
+			token, err := pollForTestToken(t, 1)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("PollForToken: %v", err)
+				}
+				if token.AccessToken != "access" {
+					t.Errorf("access token = %q, want %q", token.AccessToken, "access")
+				}
+			} else {
+				var authErr *AuthenticationError
+				if !errors.As(err, &authErr) || authErr.Code != tt.wantCode {
+					t.Fatalf("PollForToken error = %v, want code %s", err, tt.wantCode)
+				}
+			}
+
+			if got := len(endpoint.requestTimes()); got != tt.wantRequests {
+				t.Errorf("token requests = %d, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestPollForTokenSlowDown(t *testing.T) {
+	shortenPollingIntervals(t)
+	pollingIntervalUnit = 10 * time.Millisecond
+	endpoint := newTokenEndpoint(t,
+		tokenReply{http.StatusBadRequest, `{"error":"slow_down"}`},
+		pendingReply,
+		tokenIssued,
+	)
+
+	if _, err := pollForTestToken(t, 1); err != nil {
+		t.Fatalf("PollForToken: %v", err)
+	}
+
+	requests := endpoint.requestTimes()
+	if len(requests) != 3 {
+		t.Fatalf("token requests = %d, want 3", len(requests))
+	}
+	// After slow_down the interval grows by 5 units and stays that way for the rest of the polling.
+	want := (1 + slowDownIncrement) * pollingIntervalUnit
+	for i := 1; i < len(requests); i++ {
+		if gap := requests[i].Sub(requests[i-1]); gap < want {
+			t.Errorf("gap before request %d = %v, want at least %v", i+1, gap, want)
+		}
+	}
+}
+
+func TestPollForTokenCancelled(t *testing.T) {
+	shortenPollingIntervals(t)
+	newTokenEndpoint(t, pendingReply)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	_, err := PollForToken(ctx, &DeviceCodeResponse{DeviceCode: "device", ExpiresIn: 60, Interval: 1})
+	var authErr *AuthenticationError
+	if !errors.As(err, &authErr) || authErr.Code != ErrLoginCancelled {
+		t.Fatalf("PollForToken error = %v, want code %s", err, ErrLoginCancelled)
+	}
+}
+
+func TestPollForTokenRetriesHungRequest(t *testing.T) {
+	shortenPollingIntervals(t)
+	timeout := pollRequestTimeout
+	pollRequestTimeout = 20 * time.Millisecond
+	t.Cleanup(func() { pollRequestTimeout = timeout })
+
+	endpoint := newTokenEndpoint(t, tokenReply{http.StatusBadRequest, `{"error":"access_denied"}`}, tokenIssued)
+	endpoint.hangFirst = 5 * time.Second
+
+	if _, err := pollForTestToken(t, 1); err != nil {
+		t.Fatalf("PollForToken: %v", err)
+	}
+	if got := len(endpoint.requestTimes()); got != 2 {
+		t.Errorf("token requests = %d, want the hung request abandoned and a second one", got)
+	}
+}
+
+func TestPollForTokenKeepsConfiguredTimeout(t *testing.T) {
+	shortenPollingIntervals(t)
+	t.Setenv("MISSIONS_CLI_TIMEOUT", "20ms")
+
+	// The first reply would deny access, but it comes after the configured timeout, so it is never read.
+	endpoint := newTokenEndpoint(t, tokenReply{http.StatusBadRequest, `{"error":"access_denied"}`}, tokenIssued)
+	endpoint.hangFirst = 2 * time.Second
+
+	if _, err := pollForTestToken(t, 1); err != nil {
+		t.Fatalf("PollForToken: %v, want the configured timeout to cut the first request", err)
+	}
+	if got := len(endpoint.requestTimes()); got != 2 {
+		t.Errorf("token requests = %d, want 2", got)
+	}
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+	"github.com/eutika/eu-missions-cli/internal/transport"
+)
+
+// oauthErrorResponse is the error body returned by the token endpoint (RFC 6749, section 5.2).
+type oauthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// requestTokenRefresh exchanges a refresh token for a new token pair at the token endpoint.
+func requestTokenRefresh(refreshToken string) (*TokenResponse, error) {
+	payload := map[string]string{
+		"client_id":     config.NewConfig().GetClientID(),
+		"grant_type":    "refresh_token",
+		"refresh_token": refreshToken,
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, NewTokenRefreshError(fmt.Errorf("error creating refresh token request JSON: %w", err))
+	}
+
+	const requestTimeout = 10 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	tokenURL := config.NewConfig().GetTokenURL()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, NewTokenRefreshError(fmt.Errorf("error creating refresh token request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client, err := transport.NewClient(config.NewConfig())
+	if err != nil {
+		return nil, NewTokenRefreshError(fmt.Errorf("error configuring HTTP client: %w", err))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, NewTokenRefreshError(fmt.Errorf("error sending refresh token request: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errorResponse oauthErrorResponse
+		if decodeErr := json.NewDecoder(resp.Body).Decode(&errorResponse); decodeErr != nil {
+			return nil, NewTokenRefreshError(fmt.Errorf("refresh token request failed with status: %d", resp.StatusCode))
+		}
+
+		// invalid_grant means the refresh token was revoked, expired or already rotated:
+		// the only way forward is a new interactive login.
+		if errorResponse.Error == "invalid_grant" {
+			return nil, NewSessionExpiredError(errors.New("refresh token rejected by the server"))
+		}
+		return nil, NewTokenRefreshError(fmt.Errorf("refresh token request failed with status %d: %s %s",
+			resp.StatusCode, errorResponse.Error, errorResponse.ErrorDescription))
+	}
+
+	var token TokenResponse
+	if decodeErr := json.NewDecoder(resp.Body).Decode(&token); decodeErr != nil {
+		return nil, NewTokenRefreshError(fmt.Errorf("error decoding refresh token response: %w", decodeErr))
+	}
+	if token.AccessToken == "" {
+		return nil, NewTokenRefreshError(errors.New("refresh token response does not contain an access token"))
+	}
+
+	return &token, nil
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+	"github.com/eutika/eu-missions-cli/internal/transport"
+)
+
+// Token type hints defined by RFC 7009, section 2.1.
+const (
+	accessTokenHint  = "access_token"
+	refreshTokenHint = "refresh_token"
+)
+
+// RevokeToken asks the authorization server of the active profile to invalidate a token (RFC 7009).
+func RevokeToken(token, tokenTypeHint string) error {
+	return revokeToken(config.NewConfig(), token, tokenTypeHint)
+}
+
+// revokeToken asks the authorization server configured in cfg to invalidate a token.
+func revokeToken(cfg *config.Config, token, tokenTypeHint string) error {
+	form := url.Values{}
+	form.Set("token", token)
+	form.Set("token_type_hint", tokenTypeHint)
+	form.Set("client_id", cfg.GetClientID())
+
+	const requestTimeout = 10 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	revocationURL := cfg.GetRevocationURL()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, revocationURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return NewTokenRevocationError(fmt.Errorf("error creating revocation request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client, err := transport.NewClient(cfg)
+	if err != nil {
+		return NewTokenRevocationError(fmt.Errorf("error configuring HTTP client: %w", err))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return NewTokenRevocationError(fmt.Errorf("error sending revocation request: %w", err))
+	}
+	defer resp.Body.Close()
+
+	// The server answers 200 for unknown or already invalid tokens too, so anything else is a real failure.
+	if resp.StatusCode != http.StatusOK {
+		return NewTokenRevocationError(fmt.Errorf("revocation request failed with status: %d", resp.StatusCode))
+	}
+
+	return nil
+}
@@ -0,0 +1,259 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+	"github.com/eutika/eu-missions-cli/internal/transport"
+)
+
+// webLoginTimeout is how long the loopback listener waits for the browser to come back.
+const webLoginTimeout = 5 * time.Minute
+
+const callbackPath = "/callback"
+
+const callbackPage = `<!DOCTYPE html>
+<html lang="es"><head><meta charset="utf-8"><title>Missions CLI</title></head>
+<body style="font-family: sans-serif; text-align: center; margin-top: 4em">
+<h1>%s</h1><p>%s</p></body></html>`
+
+// pkceChallenge holds the PKCE verifier and its S256 challenge (RFC 7636).
+type pkceChallenge struct {
+	verifier  string
+	challenge string
+}
+
+// newPKCEChallenge creates a random code verifier and derives its S256 challenge.
+func newPKCEChallenge() (*pkceChallenge, error) {
+	verifier, err := randomURLSafe()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	return &pkceChallenge{
+		verifier:  verifier,
+		challenge: base64.RawURLEncoding.EncodeToString(sum[:]),
+	}, nil
+}
+
+// randomURLSafe returns 32 random bytes encoded as unpadded base64url, 43 characters long.
+func randomURLSafe() (string, error) {
+	const randomBytes = 32
+	buf := make([]byte, randomBytes)
+	if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// callbackResult is what the loopback listener received from the browser.
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// webLogin authenticates with the authorization code flow and PKCE, receiving the code
+// on a temporary HTTP listener bound to the loopback interface.
+func (s *AuthService) webLogin(ctx context.Context, opts LoginOptions) error {
+	fmt.Println("\n🔐 Iniciando el proceso de autenticación con Missions en el navegador...")
+
+	token, err := authorizeInBrowser(ctx, opts)
+	if err != nil {
+		if loginErr := describeLoginError(err); loginErr != nil {
+			return loginErr
+		}
+		fmt.Printf("🚫 No ha sido posible obtener el token de autenticación de Missions %s\n", err)
+		return errors.New("🚫 No ha sido posible obtener el token de autenticación de Missions")
+	}
+
+	if saveErr := SaveTokens(token); saveErr != nil {
+		return errors.New("🚫 No ha sido posible guardar el token de autenticación de Missions en tu sistema")
+	}
+
+	fmt.Println("\n✅ ¡Enhorabuena, te has autenticado con Missions!")
+	return nil
+}
+
+// authorizeInBrowser runs the browser part of the flow and exchanges the resulting code for tokens.
+func authorizeInBrowser(ctx context.Context, opts LoginOptions) (*TokenResponse, error) {
+	pkce, err := newPKCEChallenge()
+	if err != nil {
+		return nil, NewAuthorizationError(fmt.Errorf("error creating PKCE challenge: %w", err))
+	}
+	state, err := randomURLSafe()
+	if err != nil {
+		return nil, NewAuthorizationError(fmt.Errorf("error creating state: %w", err))
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, NewAuthorizationError(fmt.Errorf("error starting loopback listener: %w", err))
+	}
+	redirectURI := fmt.Sprintf("http://%s%s", listener.Addr().String(), callbackPath)
+
+	results := make(chan callbackResult, 1)
+	server := &http.Server{
+		Handler:           newCallbackHandler(state, results),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if serveErr := server.Serve(listener); serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+			results <- callbackResult{err: serveErr}
+		}
+	}()
+	defer func() {
+		const shutdownTimeout = 5 * time.Second
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}()
+
+	authorizationURL, err := buildAuthorizationURL(redirectURI, state, pkce.challenge)
+	if err != nil {
+		return nil, NewAuthorizationError(err)
+	}
+
+	fmt.Printf("\n   Accede con tu navegador a: %s\n", authorizationURL)
+	if !opts.NoBrowser && hasDesktopSession() {
+		if openErr := openBrowser(authorizationURL); openErr == nil {
+			fmt.Println("\n🌐 Se ha abierto la página de autorización en tu navegador")
+		}
+	}
+	fmt.Println("\n⏳ Esperando autorización...")
+
+	var result callbackResult
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		return nil, NewLoginCancelledError(ctx.Err())
+	case <-time.After(webLoginTimeout):
+		return nil, NewAuthorizationError(errors.New("tiempo de espera por la autorización agotado"))
+	}
+	if result.err != nil {
+		return nil, NewAuthorizationError(result.err)
+	}
+
+	return exchangeAuthorizationCode(ctx, result.code, redirectURI, pkce.verifier)
+}
+
+// buildAuthorizationURL adds the authorization request parameters to the configured endpoint.
+func buildAuthorizationURL(redirectURI, state, challenge string) (string, error) {
+	cfg := config.NewConfig()
+	authorizationURL, err := url.Parse(cfg.GetAuthorizationURL())
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization URL: %w", err)
+	}
+
+	query := authorizationURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", cfg.GetClientID())
+	query.Set("redirect_uri", redirectURI)
+	query.Set("state", state)
+	query.Set("code_challenge", challenge)
+	query.Set("code_challenge_method", "S256")
+	authorizationURL.RawQuery = query.Encode()
+
+	return authorizationURL.String(), nil
+}
+
+// newCallbackHandler handles the redirect from the authorization server. Only the first
+// valid callback is reported; requests with a wrong state are rejected without ending the flow.
+func newCallbackHandler(state string, results chan<- callbackResult) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+		if query.Get("state") != state {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, callbackPage, "Solicitud no válida", "El parámetro state no coincide.")
+			return
+		}
+
+		var result callbackResult
+		if authErr := query.Get("error"); authErr != "" {
+			result.err = fmt.Errorf("authorization denied: %s %s", authErr, query.Get("error_description"))
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprintf(w, callbackPage, "Autorización cancelada", "Puedes cerrar esta ventana.")
+		} else if result.code = query.Get("code"); result.code == "" {
+			result.err = errors.New("authorization response without code")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, callbackPage, "Solicitud no válida", "Falta el código de autorización.")
+		} else {
+			fmt.Fprintf(w, callbackPage, "¡Autenticado!", "Ya puedes cerrar esta ventana y volver al terminal.")
+		}
+
+		select {
+		case results <- result:
+		default:
+		}
+	})
+	return mux
+}
+
+// exchangeAuthorizationCode redeems the authorization code at the token endpoint.
+func exchangeAuthorizationCode(ctx context.Context, code, redirectURI, verifier string) (*TokenResponse, error) {
+	payload := map[string]string{
+		"client_id":     config.NewConfig().GetClientID(),
+		"grant_type":    "authorization_code",
+		"code":          code,
+		"redirect_uri":  redirectURI,
+		"code_verifier": verifier,
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, NewAuthorizationError(fmt.Errorf("error creating token request JSON: %w", err))
+	}
+
+	const requestTimeout = 10 * time.Second
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	tokenURL := config.NewConfig().GetTokenURL()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, NewAuthorizationError(fmt.Errorf("error creating token request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client, err := transport.NewClient(config.NewConfig())
+	if err != nil {
+		return nil, NewAuthorizationError(fmt.Errorf("error configuring HTTP client: %w", err))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, NewAuthorizationError(fmt.Errorf("error sending token request: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errorResponse oauthErrorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&errorResponse)
+		return nil, NewAuthorizationError(fmt.Errorf("token request failed with status %d: %s %s",
+			resp.StatusCode, errorResponse.Error, errorResponse.ErrorDescription))
+	}
+
+	var token TokenResponse
+	if decodeErr := json.NewDecoder(resp.Body).Decode(&token); decodeErr != nil {
+		return nil, NewAuthorizationError(fmt.Errorf("error decoding token response: %w", decodeErr))
+	}
+	if token.AccessToken == "" {
+		return nil, NewAuthorizationError(errors.New("token response does not contain an access token"))
+	}
+
+	return &token, nil
+}
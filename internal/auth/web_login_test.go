@@ -0,0 +1,275 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// authServer is a stand-in authorization server for the authorization code flow with PKCE.
+type authServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	challenge string
+	// deny makes the authorization endpoint redirect back with an error instead of a code.
+	deny bool
+	// tokenRequests records the body of every request to the token endpoint.
+	tokenRequests []map[string]string
+}
+
+const testAuthorizationCode = "test-code"
+
+func newAuthServer(t *testing.T) *authServer {
+	t.Helper()
+	s := &authServer{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		s.mu.Lock()
+		s.challenge = query.Get("code_challenge")
+		deny := s.deny
+		s.mu.Unlock()
+
+		if query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" {
+			http.Error(w, "unsupported authorization request", http.StatusBadRequest)
+			return
+		}
+
+		redirect, err := url.Parse(query.Get("redirect_uri"))
+		if err != nil {
+			http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+			return
+		}
+		params := url.Values{"state": {query.Get("state")}}
+		if deny {
+			params.Set("error", "access_denied")
+			params.Set("error_description", "the user denied access")
+		} else {
+			params.Set("code", testAuthorizationCode)
+		}
+		redirect.RawQuery = params.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		s.mu.Lock()
+		s.tokenRequests = append(s.tokenRequests, body)
+		challenge := s.challenge
+		s.mu.Unlock()
+
+		sum := sha256.Sum256([]byte(body["code_verifier"]))
+		w.Header().Set("Content-Type", "application/json")
+		if body["grant_type"] != "authorization_code" || body["code"] != testAuthorizationCode ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(oauthErrorResponse{Error: "invalid_grant", ErrorDescription: "bad code or verifier"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer", ExpiresIn: 3600,
+		})
+	})
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("MISSIONS_CLI_AUTHORIZATION_URL", s.URL+"/authorize")
+	t.Setenv("MISSIONS_CLI_TOKEN_URL", s.URL+"/token")
+	return s
+}
+
+// captureAuthorizationURL redirects the standard output while the flow runs and returns
+// the authorization URL it prints, as a user would copy it into the browser.
+func captureAuthorizationURL(t *testing.T) <-chan string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	t.Cleanup(func() {
+		os.Stdout = stdout
+		_ = writer.Close()
+	})
+
+	urls := make(chan string, 1)
+	go func() {
+		const prefix = "Accede con tu navegador a: "
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			if _, after, found := strings.Cut(scanner.Text(), prefix); found {
+				urls <- after
+				break
+			}
+		}
+		_, _ = io.Copy(io.Discard, reader)
+	}()
+	return urls
+}
+
+// visit follows a URL like the browser would, up to the loopback callback, and returns the final status.
+func visit(t *testing.T, rawURL string) int {
+	t.Helper()
+	resp, err := http.Get(rawURL) // #nosec G107 -- the URL points to the test servers.
+	if err != nil {
+		t.Errorf("visiting %s: %v", rawURL, err)
+		return 0
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return resp.StatusCode
+}
+
+func runWebLogin(t *testing.T, browse func(authorizationURL string)) (*TokenResponse, error) {
+	t.Helper()
+	urls := captureAuthorizationURL(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	browsed := make(chan struct{})
+	go func() {
+		defer close(browsed)
+		select {
+		case authorizationURL := <-urls:
+			browse(authorizationURL)
+		case <-ctx.Done():
+		}
+	}()
+
+	token, err := authorizeInBrowser(ctx, LoginOptions{NoBrowser: true})
+	// The flow ends as soon as the callback arrives; let the browser finish before looking at it.
+	cancel()
+	<-browsed
+	return token, err
+}
+
+func TestWebLoginExchangesCodeWithVerifier(t *testing.T) {
+	server := newAuthServer(t)
+
+	var callbackStatus int
+	token, err := runWebLogin(t, func(authorizationURL string) {
+		callbackStatus = visit(t, authorizationURL)
+	})
+	if err != nil {
+		t.Fatalf("authorizeInBrowser: %v", err)
+	}
+	if token.AccessToken != "access" || token.RefreshToken != "refresh" {
+		t.Errorf("token = %+v, want the tokens issued by the server", token)
+	}
+	if callbackStatus != http.StatusOK {
+		t.Errorf("callback status = %d, want %d", callbackStatus, http.StatusOK)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if len(server.tokenRequests) != 1 {
+		t.Fatalf("token requests = %d, want 1", len(server.tokenRequests))
+	}
+	request := server.tokenRequests[0]
+	if request["code_verifier"] == "" || request["code_verifier"] == server.challenge {
+		t.Errorf("code_verifier = %q, want the verifier behind challenge %q", request["code_verifier"], server.challenge)
+	}
+	if !strings.HasPrefix(request["redirect_uri"], "http://127.0.0.1:") {
+		t.Errorf("redirect_uri = %q, want the loopback listener", request["redirect_uri"])
+	}
+}
+
+func TestWebLoginErrorRedirect(t *testing.T) {
+	server := newAuthServer(t)
+	server.deny = true
+
+	var callbackStatus int
+	_, err := runWebLogin(t, func(authorizationURL string) {
+		callbackStatus = visit(t, authorizationURL)
+	})
+	var authErr *AuthenticationError
+	if !errors.As(err, &authErr) || authErr.Code != ErrAuthorization || !strings.Contains(err.Error(), "access_denied") {
+		t.Fatalf("authorizeInBrowser error = %v, want an authorization error with access_denied", err)
+	}
+	if callbackStatus != http.StatusForbidden {
+		t.Errorf("callback status = %d, want %d", callbackStatus, http.StatusForbidden)
+	}
+	if len(server.tokenRequests) != 0 {
+		t.Errorf("token requests = %d, want none after an error redirect", len(server.tokenRequests))
+	}
+}
+
+func TestWebLoginIgnoresStateMismatch(t *testing.T) {
+	server := newAuthServer(t)
+
+	var forgedStatus int
+	token, err := runWebLogin(t, func(authorizationURL string) {
+		parsed, parseErr := url.Parse(authorizationURL)
+		if parseErr != nil {
+			t.Errorf("parsing %s: %v", authorizationURL, parseErr)
+			return
+		}
+		// A forged callback with another state is rejected and does not end the flow...
+		forged := parsed.Query().Get("redirect_uri") + "?" + url.Values{
+			"state": {"forged"}, "code": {"attacker-code"},
+		}.Encode()
+		forgedStatus = visit(t, forged)
+
+		// ...so the real one still completes it.
+		visit(t, authorizationURL)
+	})
+	if err != nil {
+		t.Fatalf("authorizeInBrowser: %v", err)
+	}
+	if token.AccessToken != "access" {
+		t.Errorf("access token = %q, want %q", token.AccessToken, "access")
+	}
+	if forgedStatus != http.StatusBadRequest {
+		t.Errorf("forged callback status = %d, want %d", forgedStatus, http.StatusBadRequest)
+	}
+	for _, request := range server.tokenRequests {
+		if request["code"] == "attacker-code" {
+			t.Error("the code of a callback with a wrong state was exchanged")
+		}
+	}
+}
+
+func TestExchangeAuthorizationCodeRejectsWrongVerifier(t *testing.T) {
+	server := newAuthServer(t)
+	pkce, err := newPKCEChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.challenge = pkce.challenge
+
+	_, err = exchangeAuthorizationCode(context.Background(), testAuthorizationCode, "http://127.0.0.1/callback", "wrong-verifier")
+	var authErr *AuthenticationError
+	if !errors.As(err, &authErr) || authErr.Code != ErrAuthorization || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Fatalf("exchange with a wrong verifier error = %v, want an invalid_grant authorization error", err)
+	}
+
+	token, err := exchangeAuthorizationCode(context.Background(), testAuthorizationCode, "http://127.0.0.1/callback", pkce.verifier)
+	if err != nil {
+		t.Fatalf("exchange with the right verifier: %v", err)
+	}
+	if token.AccessToken != "access" {
+		t.Errorf("access token = %q, want %q", token.AccessToken, "access")
+	}
+}
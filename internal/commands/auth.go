@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eutika/eu-missions-cli/internal/auth"
+)
+
+func NewAuthCommand(authService *auth.AuthService) *cobra.Command {
+	authCmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Gestiona la autenticación de la CLI con Missions",
+	}
+
+	authCmd.AddCommand(newAuthStatusCommand(authService, "status"))
+
+	return authCmd
+}
+
+func NewWhoamiCommand(authService *auth.AuthService) *cobra.Command {
+	return newAuthStatusCommand(authService, "whoami")
+}
+
+func newAuthStatusCommand(authService *auth.AuthService, use string) *cobra.Command {
+	return &cobra.Command{
+		Use:   use,
+		Short: "Muestra el estado de la sesión en Missions",
+		Long: "Este comando muestra la cuenta con la que estás autenticado, cuándo caduca la sesión y dónde se " +
+			"guardan las credenciales. Termina con error si la sesión no se puede usar",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return printSessionStatus(authService.Status())
+		},
+	}
+}
+
+func printSessionStatus(status *auth.SessionStatus) error {
+	fmt.Println("\n🔐 Estado de la sesión en Missions")
+	fmt.Println("─────────────────────────────────")
+	fmt.Printf("  👥 Perfil: %s\n", status.Profile)
+
+	switch {
+	case status.FromEnvironment:
+		fmt.Println("  🗄️  Almacenamiento: variable MISSIONS_TOKEN (no se guarda)")
+	case status.StorageError != nil:
+		fmt.Printf("  🗄️  Almacenamiento: no disponible (%v)\n", status.StorageError)
+	case status.Backend == auth.BackendKeyring:
+		fmt.Println("  🗄️  Almacenamiento: keyring del sistema")
+	case status.Backend == auth.BackendFile:
+		fmt.Printf("  🗄️  Almacenamiento: archivo cifrado (%s)\n", status.FallbackPath)
+	case status.Backend == auth.BackendHelper:
+		fmt.Println("  🗄️  Almacenamiento: credential helper externo")
+	case status.Backend == auth.BackendEnv:
+		fmt.Println("  🗄️  Almacenamiento: variables de entorno (solo lectura)")
+	default:
+		fmt.Printf("  🗄️  Almacenamiento: %s\n", status.Backend)
+	}
+
+	if !status.LoggedIn {
+		fmt.Println("  🚫 No has iniciado sesión")
+		fmt.Println()
+		return errors.New("🚫 No hay ninguna sesión iniciada, ejecuta 'missions login'")
+	}
+
+	if identity := status.Identity; identity != nil {
+		fmt.Printf("  👤 Usuario: %s", displayName(identity))
+		if identity.Source == auth.IdentityFromToken {
+			fmt.Print(" (según el token)")
+		}
+		fmt.Println()
+	} else {
+		fmt.Println("  👤 Usuario: desconocido")
+	}
+
+	if !status.ExpiresAt.IsZero() {
+		expiresAt := status.ExpiresAt.Local().Format("2006-01-02 15:04:05")
+		if remaining := time.Until(status.ExpiresAt); remaining > 0 {
+			fmt.Printf("  ⏰ Caduca: %s (en %s)\n", expiresAt, remaining.Round(time.Minute))
+		} else {
+			fmt.Printf("  ⏰ Caducó: %s\n", expiresAt)
+		}
+	}
+
+	if status.HasRefreshToken {
+		fmt.Println("  🔄 Token de refresco: sí")
+	} else {
+		fmt.Println("  🔄 Token de refresco: no")
+	}
+	fmt.Println()
+
+	if !status.Usable() {
+		return errors.New("🚫 La sesión ha caducado, ejecuta 'missions login' de nuevo")
+	}
+	return nil
+}
+
+func displayName(identity *auth.Identity) string {
+	name := identity.Name
+	if name == "" {
+		name = identity.Username
+	}
+
+	switch {
+	case name != "" && identity.Email != "":
+		return fmt.Sprintf("%s <%s>", name, identity.Email)
+	case name != "":
+		return name
+	case identity.Email != "":
+		return identity.Email
+	case identity.Subject != "":
+		return identity.Subject
+	default:
+		return "desconocido"
+	}
+}
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/eutika/eu-missions-cli/internal/auth"
+)
+
+// discardStdout sends what the command prints to the terminal nowhere for the rest of the test.
+func discardStdout(t *testing.T) {
+	t.Helper()
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = devNull
+	t.Cleanup(func() {
+		os.Stdout = stdout
+		devNull.Close()
+	})
+}
+
+func TestPrintSessionStatusFailsWhenUnusable(t *testing.T) {
+	discardStdout(t)
+
+	tests := []struct {
+		name    string
+		status  auth.SessionStatus
+		wantErr bool
+	}{
+		{name: "not logged in", status: auth.SessionStatus{Backend: auth.BackendKeyring}, wantErr: true},
+		{
+			name:   "valid session",
+			status: auth.SessionStatus{Backend: auth.BackendFile, LoggedIn: true, ExpiresAt: time.Now().Add(time.Hour)},
+		},
+		{
+			name:   "expired with refresh token",
+			status: auth.SessionStatus{LoggedIn: true, Expired: true, HasRefreshToken: true, ExpiresAt: time.Now().Add(-time.Hour)},
+		},
+		{
+			name:    "expired without refresh token",
+			status:  auth.SessionStatus{LoggedIn: true, Expired: true, ExpiresAt: time.Now().Add(-time.Hour)},
+			wantErr: true,
+		},
+		{name: "token from the environment", status: auth.SessionStatus{LoggedIn: true, FromEnvironment: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := printSessionStatus(&tt.status); (err != nil) != tt.wantErr {
+				t.Errorf("printSessionStatus = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDisplayName(t *testing.T) {
+	tests := []struct {
+		identity auth.Identity
+		want     string
+	}{
+		{auth.Identity{Name: "Ana", Email: "ana@example.com", Username: "ana"}, "Ana <ana@example.com>"},
+		{auth.Identity{Username: "ana", Email: "ana@example.com"}, "ana <ana@example.com>"},
+		{auth.Identity{Name: "Ana"}, "Ana"},
+		{auth.Identity{Email: "ana@example.com", Subject: "42"}, "ana@example.com"},
+		{auth.Identity{Subject: "42"}, "42"},
+		{auth.Identity{}, "desconocido"},
+	}
+
+	for _, tt := range tests {
+		if got := displayName(&tt.identity); got != tt.want {
+			t.Errorf("displayName(%+v) = %q, want %q", tt.identity, got, tt.want)
+		}
+	}
+}
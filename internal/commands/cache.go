@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eutika/eu-missions-cli/internal/services"
+)
+
+func NewCacheCommand(remoteService *services.RemoteService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Gestiona la caché local de etapas",
+		Long: "Las etapas descargadas de Missions se guardan en una caché local que se revalida en cada uso " +
+			"y permite seguir trabajando sin conexión",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:          "clear",
+		Short:        "Elimina las etapas guardadas en la caché",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := remoteService.ClearCache(); err != nil {
+				return fmt.Errorf("🚫 No ha sido posible vaciar la caché: %w", err)
+			}
+			fmt.Println("🧹 Caché de etapas vaciada")
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+// printStaleNotice warns when a stage definition comes from the cache because Missions could not be reached.
+func printStaleNotice(cmd *cobra.Command, stage *services.Stage) {
+	if stage.Stale {
+		cmd.PrintErrf("📴 Sin conexión con Missions: se usa la copia de la etapa guardada el %s, "+
+			"que puede estar desactualizada\n", stage.FetchedAt.Local().Format(time.DateTime))
+	}
+}
@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+)
+
+// skipProjectEnvAnnotation marks the commands that must not load the project .env file.
+const skipProjectEnvAnnotation = "skip-project-env"
+
+// SkipsProjectEnv reports whether cmd inspects the project .env file instead of using it.
+func SkipsProjectEnv(cmd *cobra.Command) bool {
+	return cmd.Annotations[skipProjectEnvAnnotation] == "true"
+}
+
+func NewEnvCommand() *cobra.Command {
+	envCmd := &cobra.Command{
+		Use:   "env",
+		Short: "Gestiona la confianza en el archivo .env del directorio actual",
+		Long: "Un archivo .env en el directorio actual solo puede cambiar las variables MISSIONS_* de la CLI, " +
+			"y las que deciden dónde guarda sus archivos, qué programas se ejecutan o por dónde se conectan " +
+			"(PATH, HOME, XDG_*, LD_*, *_PROXY...), si lo apruebas. El resto de variables se cargan siempre. " +
+			"Solo se muestran los valores de los servidores y el perfil; los demás se ocultan por si son contraseñas. " +
+			"La aprobación se guarda para ese contenido exacto: si el archivo cambia, se vuelve a pedir",
+	}
+
+	envCmd.AddCommand(
+		newEnvStatusCommand(),
+		newEnvAllowCommand(),
+		newEnvDenyCommand(),
+	)
+	for _, cmd := range envCmd.Commands() {
+		cmd.Annotations = map[string]string{skipProjectEnvAnnotation: "true"}
+	}
+
+	return envCmd
+}
+
+func newEnvStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Muestra qué cambia el archivo .env y si está aprobado",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			env, err := readProjectEnv()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("\n📄 %s\n", env.Path)
+			if len(env.Overrides) == 0 {
+				fmt.Println("  ℹ️  No cambia la configuración de la CLI ni variables que necesiten aprobación")
+				return nil
+			}
+			if env.Trusted() {
+				fmt.Println("  ✅ Aprobado")
+			} else {
+				fmt.Println("  🚫 No aprobado: estas variables se ignoran")
+			}
+			fmt.Println()
+			config.PrintProjectEnvDiff(os.Stdout, env)
+			return nil
+		},
+	}
+}
+
+func newEnvAllowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "allow",
+		Short: "Aprueba el contenido actual del archivo .env",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			env, err := readProjectEnv()
+			if err != nil {
+				return err
+			}
+			if len(env.Overrides) == 0 {
+				fmt.Println("ℹ️  El archivo .env no cambia la configuración de la CLI ni variables que necesiten aprobación, no hace falta aprobarlo")
+				return nil
+			}
+
+			fmt.Printf("\n📄 %s\n\n", env.Path)
+			config.PrintProjectEnvDiff(os.Stdout, env)
+			if err := config.AllowProjectEnv(env); err != nil {
+				return fmt.Errorf("🚫 No ha sido posible guardar la aprobación: %w", err)
+			}
+
+			fmt.Println("\n✅ Archivo .env aprobado")
+			return nil
+		},
+	}
+}
+
+func newEnvDenyCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "deny",
+		Short: "Retira la aprobación del archivo .env",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			env, err := readProjectEnv()
+			if err != nil {
+				return err
+			}
+
+			denied, err := config.DenyProjectEnv(env.Path)
+			if err != nil {
+				return fmt.Errorf("🚫 No ha sido posible retirar la aprobación: %w", err)
+			}
+			if !denied {
+				fmt.Println("ℹ️  El archivo .env no estaba aprobado")
+				return nil
+			}
+
+			fmt.Println("✅ Aprobación retirada")
+			return nil
+		},
+	}
+}
+
+func readProjectEnv() (*config.ProjectEnv, error) {
+	env, err := config.ReadProjectEnv()
+	if err != nil {
+		return nil, fmt.Errorf("🚫 No ha sido posible leer el archivo .env: %w", err)
+	}
+	if env == nil {
+		return nil, errors.New("🚫 No hay ningún archivo .env en el directorio actual")
+	}
+	return env, nil
+}
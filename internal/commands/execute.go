@@ -26,11 +26,14 @@ func NewExecuteCommand(remoteService *services.RemoteService, executor *CommandE
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			// Fetch command from remote
-			command, err := ec.remoteService.FetchCommands(args[0])
+			stage, err := ec.remoteService.FetchStage(cmd.Context(), args[0])
 			if err != nil {
 				cmd.PrintErrf("❌ Error al recuperar el comando: %v\n", err)
 				os.Exit(1)
 			}
+			printStaleNotice(cmd, stage)
+			printStageHeader(stage)
+			command := stage.Commands
 
 			// Confirm execution
 			if !ec.executor.ConfirmExecution(command) {
@@ -53,47 +56,14 @@ func NewExecuteCommand(remoteService *services.RemoteService, executor *CommandE
 			fmt.Println(output)
 
 			// Send result back to remote endpoint
-			response, sendErr := ec.remoteService.SendCommandResult("submit", args[0], output)
+			response, sendErr := ec.remoteService.SendCommandResult(cmd.Context(), "submit", args[0], output)
 			if sendErr != nil {
 				cmd.PrintErrf("❌ Error al enviar el resultado del comando: %v\n", sendErr)
+				printQueuedHint(cmd, sendErr)
 				os.Exit(1)
 			}
-			commands, isArray := response["commands"].([]interface{})
-			if !isArray {
-				cmd.PrintErrf("❌ Formato de respuesta inválido: el campo commands no es un array\n")
-				os.Exit(1)
-			}
-
-			fmt.Println("\n📊 Detalle de comandos:")
-			fmt.Println("─────────────────────")
-			for _, cmdData := range commands {
-				if cmdMap, isMap := cmdData.(map[string]interface{}); isMap {
-					isCorrect := cmdMap["isCorrect"].(bool)
-					statusIcon := "✅"
-					if !isCorrect {
-						statusIcon = "❌"
-					}
-					fmt.Printf("  %s  %s\n", statusIcon, cmdMap["command"])
-				}
-			}
-
-			fmt.Println("\n🏁 Resultado final:")
-			fmt.Println("────────────────")
-
-			isValid := response["isValid"].(bool)
-			percentageCorrect := response["percentageCorrect"].(float64)
-			requiredPercentage := response["requiredCorrectPercentage"].(float64)
-
-			resultIcon := "🎉"
-			resultText := "ETAPA COMPLETADA"
-			if !isValid {
-				resultIcon = "❌"
-				resultText = "ETAPA NO COMPLETADA"
-			}
 
-			fmt.Printf("  %s %s\n", resultIcon, resultText)
-			fmt.Printf("  ➡️ Porcentaje de acierto: %.0f%% (requerido: %.0f%%)\n\n",
-				percentageCorrect, requiredPercentage)
+			printVerdict(response, "ETAPA COMPLETADA", "ETAPA NO COMPLETADA")
 		},
 	}
 }
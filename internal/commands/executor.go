@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/eutika/eu-missions-cli/internal/config"
+	"github.com/eutika/eu-missions-cli/internal/prompt"
 )
 
 type CommandExecutor struct {
@@ -35,8 +36,11 @@ func (e *CommandExecutor) ValidateCommand(command string) error {
 	return nil
 }
 
+// ExecuteCommand runs the commands of a stage and returns their combined output. The output of
+// each command, and of all of them together, is capped at the configured sizes.
 func (e *CommandExecutor) ExecuteCommand(commands []string) ([]string, error) {
 	results := make([]string, 0, len(commands))
+	remaining := e.config.GetMaxTotalOutput()
 
 	for _, command := range commands {
 		// Validate each command before execution
@@ -58,14 +62,18 @@ func (e *CommandExecutor) ExecuteCommand(commands []string) ([]string, error) {
 			cmd = exec.Command(userShell, "-l", "-c", command)
 		}
 
-		// Capture both stdout and stderr
-		output, err := cmd.CombinedOutput()
+		// Capture both stdout and stderr, within the output budget left for the stage
+		output, err, captureErr := runCapturing(cmd, min(e.config.GetMaxCommandOutput(), max(remaining, 0)))
+		if captureErr != nil {
+			return nil, fmt.Errorf("'%s': %w", command, captureErr)
+		}
+		remaining -= int64(len(output))
 		if err != nil {
 			// Agrega el error y la salida al array de resultados
-			results = append(results, fmt.Sprintf("🔥 Error ejecutando '%s': %v\nSalida: %s", command, err, string(output)))
+			results = append(results, fmt.Sprintf("🔥 Error ejecutando '%s': %v\nSalida: %s", command, err, output))
 			break // Si quieres continuar con el resto de comandos, elimina este break
 		}
-		results = append(results, string(output))
+		results = append(results, output)
 	}
 
 	return results, nil
@@ -75,29 +83,9 @@ func (e *CommandExecutor) ConfirmExecution(commands []string) bool {
 	fmt.Println("\n👀 Se van a ejecutar los siguientes comandos:")
 	fmt.Println("─────────────────────────────────────────")
 	for _, command := range commands {
-		fmt.Printf("  ▶️  %s\n", command)
+		fmt.Printf("  ▶️  %s\n", quoteControlChars(command))
 	}
 	fmt.Println()
 
-	for {
-		fmt.Print("¿Quieres continuar? (si/no): ")
-
-		var response string
-		if _, err := fmt.Scanln(&response); err != nil {
-			fmt.Printf("❌ Error al leer la entrada: %v\n", err)
-			continue
-		}
-
-		// Normalize input
-		response = strings.ToLower(strings.TrimSpace(response))
-
-		switch response {
-		case "sí", "si", "s", "":
-			return true
-		case "no", "n":
-			return false
-		default:
-			fmt.Println("⚠️ Por favor, contesta 'sí' o 'no'. También puedes pulsar 'Enter' para confirmar.")
-		}
-	}
+	return prompt.Confirm(os.Stdout, "¿Quieres continuar?", true)
 }
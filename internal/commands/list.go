@@ -0,0 +1,177 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eutika/eu-missions-cli/internal/services"
+	"github.com/eutika/eu-missions-cli/pkg/types"
+)
+
+// stageStatusFilters maps the values accepted by --status to the statuses reported by Missions.
+var stageStatusFilters = map[string]string{
+	"not-started": types.StageNotStarted,
+	"failed":      types.StageFailed,
+	"completed":   types.StageCompleted,
+}
+
+// missionStages is a mission together with the stages left after filtering.
+type missionStages struct {
+	mission types.Mission
+	stages  []types.StageSummary
+}
+
+func NewListCommand(remoteService *services.RemoteService) *cobra.Command {
+	var missionID, status, search string
+	var table bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Muestra tus misiones y sus etapas",
+		Long: "Muestra las misiones en las que participas con sus etapas, su estado y el último porcentaje obtenido. " +
+			"El id de cada etapa es el que se usa en validate y submit",
+		Example: "  missions list\n" +
+			"  missions list --status failed\n" +
+			"  missions list --mission m1 --table\n" +
+			"  missions list --search redes",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			statusFilter := ""
+			if status != "" {
+				var ok bool
+				if statusFilter, ok = stageStatusFilters[status]; !ok {
+					return fmt.Errorf("🚫 Estado no válido: %s (usa not-started, failed o completed)", status)
+				}
+			}
+
+			missions, err := remoteService.ListMissions(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("🚫 No ha sido posible recuperar tus misiones: %w", err)
+			}
+
+			search = strings.ToLower(strings.TrimSpace(search))
+			var listing []missionStages
+			missionFound := false
+			for _, mission := range missions {
+				if missionID != "" && mission.ID != missionID {
+					continue
+				}
+				missionFound = true
+
+				stages, stagesErr := remoteService.ListStages(cmd.Context(), mission.ID)
+				if stagesErr != nil {
+					return fmt.Errorf("🚫 No ha sido posible recuperar las etapas de %s: %w", singleLine(mission.Title), stagesErr)
+				}
+
+				missionMatches := search == "" || strings.Contains(strings.ToLower(mission.Title), search)
+				entry := missionStages{mission: mission}
+				for _, stage := range stages {
+					if statusFilter != "" && stage.Status != statusFilter {
+						continue
+					}
+					if !missionMatches && !strings.Contains(strings.ToLower(stage.Title), search) {
+						continue
+					}
+					entry.stages = append(entry.stages, stage)
+				}
+				if len(entry.stages) > 0 {
+					listing = append(listing, entry)
+				}
+			}
+
+			if missionID != "" && !missionFound {
+				return fmt.Errorf("🚫 No se ha encontrado la misión con id: %s", missionID)
+			}
+			if len(listing) == 0 {
+				if missionID != "" && status == "" && search == "" {
+					fmt.Printf("📭 La misión %s todavía no tiene etapas\n", missionID)
+					return nil
+				}
+				fmt.Println("🔍 No hay etapas que coincidan con la búsqueda")
+				return nil
+			}
+
+			if table {
+				return printStagesTable(listing)
+			}
+			printStagesTree(listing)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&missionID, "mission", "", "Muestra solo la misión con ese id")
+	cmd.Flags().StringVar(&status, "status", "", "Muestra solo las etapas con ese estado: not-started, failed o completed")
+	cmd.Flags().StringVar(&search, "search", "", "Muestra solo las misiones o etapas cuyo título contiene el texto")
+	cmd.Flags().BoolVar(&table, "table", false, "Muestra las etapas en una tabla en lugar de un árbol")
+
+	return cmd
+}
+
+func printStagesTree(listing []missionStages) {
+	fmt.Println()
+	for _, entry := range listing {
+		fmt.Printf("📚 %s (%s)\n", singleLine(entry.mission.Title), singleLine(entry.mission.ID))
+		for i, stage := range entry.stages {
+			branch := "├─"
+			if i == len(entry.stages)-1 {
+				branch = "└─"
+			}
+			fmt.Printf("  %s %s %s  [%s]", branch, stageStatusIcon(stage.Status), singleLine(stage.Title), singleLine(stage.ID))
+			if stage.LastPercentage != nil {
+				fmt.Printf("  %.0f%%", *stage.LastPercentage)
+			}
+			fmt.Println()
+		}
+		fmt.Println()
+	}
+}
+
+func printStagesTable(listing []missionStages) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MISIÓN\tETAPA\tID\tESTADO\tÚLTIMO %")
+	for _, entry := range listing {
+		for _, stage := range entry.stages {
+			percentage := "-"
+			if stage.LastPercentage != nil {
+				percentage = fmt.Sprintf("%.0f%%", *stage.LastPercentage)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				singleLine(entry.mission.Title), singleLine(stage.Title), singleLine(stage.ID),
+				stageStatusText(stage.Status), percentage)
+		}
+	}
+	return w.Flush()
+}
+
+// singleLine prepares a title or id sent by Missions to be printed in one line of the listing:
+// control characters that could send escape sequences to the terminal are dropped, like in
+// 'missions show', and line breaks and tabs become spaces so the tree and table stay aligned.
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(stripControlChars(text)), " ")
+}
+
+func stageStatusIcon(status string) string {
+	switch status {
+	case types.StageCompleted:
+		return "✅"
+	case types.StageFailed:
+		return "❌"
+	default:
+		return "⚪"
+	}
+}
+
+func stageStatusText(status string) string {
+	switch status {
+	case types.StageCompleted:
+		return "completada"
+	case types.StageFailed:
+		return "no superada"
+	default:
+		return "sin empezar"
+	}
+}
@@ -0,0 +1,26 @@
+package commands
+
+import "testing"
+
+func TestSingleLine(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "plain title", text: "Introducción a Go", want: "Introducción a Go"},
+		{name: "escape sequence", text: "Etapa \x1b]0;pwned\x07uno", want: "Etapa ]0;pwneduno"},
+		{name: "color codes", text: "\x1b[31mRoja\x1b[0m", want: "[31mRoja[0m"},
+		{name: "line breaks", text: "Primera\nSegunda\r\nTercera", want: "Primera Segunda Tercera"},
+		{name: "tabs and spaces", text: "  Uno\t\tDos  ", want: "Uno Dos"},
+		{name: "C1 control", text: "a\u009bb", want: "ab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := singleLine(tt.text); got != tt.want {
+				t.Errorf("singleLine(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
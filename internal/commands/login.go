@@ -1,19 +1,65 @@
 package commands
 
 import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 
 	"github.com/eutika/eu-missions-cli/internal/auth"
 )
 
 func NewLoginCommand(authService *auth.AuthService) *cobra.Command {
-	return &cobra.Command{
+	var opts auth.LoginOptions
+	var withToken bool
+
+	cmd := &cobra.Command{
 		Use:   "login",
 		Short: "Autentica la CLI con tu cuenta en Missions",
 		Long: "Este comando inicia un proceso de autenticación basado en OAuth2 para conectar tu cuenta de Missions " +
-			"con la CLI",
-		RunE: func(_ *cobra.Command, _ []string) error {
-			return authService.Login()
+			"con la CLI.\n\nCon --with-token lee el token de la entrada estándar, para entornos sin interacción " +
+			"como CI: un JSON con access_token, refresh_token y expires_in, o hasta tres líneas con el token de " +
+			"acceso, el de refresco y la caducidad",
+		Example: "  missions login\n" +
+			"  echo \"$TOKEN\" | missions login --with-token",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if withToken {
+				return loginWithToken(cmd, authService)
+			}
+
+			// Ctrl-C or a termination signal stops the login cleanly instead of killing the process mid-poll.
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			return authService.Login(ctx, opts)
 		},
 	}
+	cmd.Flags().BoolVar(&opts.Web, "web", false,
+		"Inicia sesión en el navegador con redirección local, para proveedores sin flujo de dispositivo")
+	cmd.Flags().BoolVar(&opts.NoBrowser, "no-browser", false,
+		"No abre el navegador ni muestra el código QR, solo la URL y el código")
+	cmd.Flags().BoolVar(&withToken, "with-token", false, "Lee el token de la entrada estándar en lugar de iniciar sesión")
+	cmd.MarkFlagsMutuallyExclusive("with-token", "web")
+	cmd.MarkFlagsMutuallyExclusive("with-token", "no-browser")
+
+	return cmd
+}
+
+func loginWithToken(cmd *cobra.Command, authService *auth.AuthService) error {
+	if term.IsTerminal(int(os.Stdin.Fd())) { // #nosec G115 -- file descriptors fit in an int.
+		fmt.Fprintln(os.Stderr, "📋 Pega el token y pulsa Ctrl-D (Ctrl-Z y Enter en Windows) para terminar:")
+	}
+
+	identity, err := authService.LoginWithToken(cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✅ Token guardado, te has autenticado con Missions como %s\n", displayName(identity))
+	return nil
 }
@@ -0,0 +1,24 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/eutika/eu-missions-cli/internal/auth"
+)
+
+func NewLogoutCommand(authService *auth.AuthService) *cobra.Command {
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "logout",
+		Short: "Cierra la sesión de la CLI en Missions",
+		Long: "Este comando revoca los tokens de la sesión actual en Missions y los elimina del keyring " +
+			"o del archivo cifrado de este equipo",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return authService.Logout(all)
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "Elimina todas las credenciales guardadas por la CLI")
+
+	return cmd
+}
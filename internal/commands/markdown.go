@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// ANSI escape sequences used to style Markdown in the terminal.
+const (
+	styleReset     = "\x1b[0m"
+	styleBold      = "\x1b[1m"
+	styleDim       = "\x1b[2m"
+	styleItalic    = "\x1b[3m"
+	styleUnderline = "\x1b[4m"
+	styleCyan      = "\x1b[36m"
+)
+
+var (
+	headingPattern        = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	listItemPattern       = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	blockquotePattern     = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	codeSpanPattern       = regexp.MustCompile("`+[^`]+`+")
+	linkPattern           = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	boldPattern           = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	italicPattern         = regexp.MustCompile(`\*([^*\s][^*]*)\*|\b_([^_\s][^_]*)_\b`)
+	horizontalRulePattern = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+)
+
+// markdownRenderer writes the subset of Markdown used in stage descriptions to a terminal:
+// headings, lists, block quotes, code blocks, inline code, emphasis and links.
+type markdownRenderer struct {
+	w      io.Writer
+	styled bool
+	indent string
+}
+
+// newMarkdownRenderer creates a renderer that only uses ANSI styles when stdout is a terminal and NO_COLOR is not set.
+func newMarkdownRenderer(w io.Writer, indent string) *markdownRenderer {
+	return &markdownRenderer{
+		w:      w,
+		styled: term.IsTerminal(int(os.Stdout.Fd())) && os.Getenv("NO_COLOR") == "",
+		indent: indent,
+	}
+}
+
+func (r *markdownRenderer) style(text string, styles ...string) string {
+	if !r.styled || text == "" {
+		return text
+	}
+	return strings.Join(styles, "") + text + styleReset
+}
+
+// Render writes the Markdown text. Control characters in it are dropped, as the text comes from
+// the server and escape sequences could rewrite what was printed before.
+func (r *markdownRenderer) Render(text string) {
+	inCodeBlock := false
+	blankPending := false
+
+	for _, line := range strings.Split(stripControlChars(text), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCodeBlock = !inCodeBlock
+			if blankPending {
+				fmt.Fprintln(r.w)
+				blankPending = false
+			}
+			continue
+		}
+		if inCodeBlock {
+			fmt.Fprintf(r.w, "%s  %s %s\n", r.indent, r.style("│", styleDim), r.style(line, styleCyan))
+			continue
+		}
+
+		// Collapse runs of blank lines into a single one.
+		if trimmed == "" {
+			blankPending = true
+			continue
+		}
+		if blankPending {
+			fmt.Fprintln(r.w)
+			blankPending = false
+		}
+
+		switch {
+		case horizontalRulePattern.MatchString(line):
+			fmt.Fprintf(r.w, "%s%s\n", r.indent, r.style(strings.Repeat("─", 30), styleDim))
+		case headingPattern.MatchString(line):
+			r.renderHeading(headingPattern.FindStringSubmatch(line))
+		case listItemPattern.MatchString(line):
+			match := listItemPattern.FindStringSubmatch(line)
+			bullet := match[2]
+			if !strings.ContainsAny(bullet[:1], "0123456789") {
+				bullet = "•"
+			}
+			fmt.Fprintf(r.w, "%s%s%s %s\n", r.indent, match[1], bullet, r.renderInline(match[3]))
+		case blockquotePattern.MatchString(line):
+			quote := blockquotePattern.FindStringSubmatch(line)[1]
+			fmt.Fprintf(r.w, "%s%s %s\n", r.indent, r.style("┃", styleDim), r.style(r.renderInline(quote), styleItalic))
+		default:
+			fmt.Fprintf(r.w, "%s%s\n", r.indent, r.renderInline(trimmed))
+		}
+	}
+}
+
+func (r *markdownRenderer) renderHeading(match []string) {
+	level, text := len(match[1]), r.renderInline(match[2])
+	if level > 2 {
+		fmt.Fprintf(r.w, "%s%s\n", r.indent, r.style(text, styleBold))
+		return
+	}
+
+	underline := "─"
+	if level == 1 {
+		underline = "═"
+	}
+	// The underline is as long as the text shown, without Markdown markers or styles.
+	plain := *r
+	plain.styled = false
+	width := utf8.RuneCountInString(plain.renderInline(match[2]))
+
+	fmt.Fprintf(r.w, "%s%s\n", r.indent, r.style(text, styleBold))
+	fmt.Fprintf(r.w, "%s%s\n", r.indent, strings.Repeat(underline, width))
+}
+
+// renderInline styles inline code, links and emphasis. Code spans are left untouched.
+func (r *markdownRenderer) renderInline(text string) string {
+	var out strings.Builder
+	last := 0
+	for _, span := range codeSpanPattern.FindAllStringIndex(text, -1) {
+		out.WriteString(r.renderEmphasis(text[last:span[0]]))
+		out.WriteString(r.style(strings.Trim(text[span[0]:span[1]], "`"), styleCyan))
+		last = span[1]
+	}
+	out.WriteString(r.renderEmphasis(text[last:]))
+	return out.String()
+}
+
+func (r *markdownRenderer) renderEmphasis(text string) string {
+	text = linkPattern.ReplaceAllStringFunc(text, func(link string) string {
+		match := linkPattern.FindStringSubmatch(link)
+		if match[1] == match[2] {
+			return r.style(match[2], styleUnderline)
+		}
+		return fmt.Sprintf("%s (%s)", r.style(match[1], styleUnderline), match[2])
+	})
+	text = boldPattern.ReplaceAllStringFunc(text, func(bold string) string {
+		match := boldPattern.FindStringSubmatch(bold)
+		return r.style(match[1]+match[2], styleBold)
+	})
+	return italicPattern.ReplaceAllStringFunc(text, func(italic string) string {
+		match := italicPattern.FindStringSubmatch(italic)
+		return r.style(match[1]+match[2], styleItalic)
+	})
+}
+
+// isControlChar reports whether r is a C0 or C1 control character other than a line break or a tab.
+func isControlChar(r rune) bool {
+	return r != '\n' && r != '\t' && (r < 0x20 || (r >= 0x7f && r <= 0x9f))
+}
+
+// stripControlChars removes the control characters that could move the cursor or send escape
+// sequences to the terminal, keeping line breaks and tabs.
+func stripControlChars(text string) string {
+	return strings.Map(func(r rune) rune {
+		if isControlChar(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// quoteControlChars shows the control characters of a command as Go escapes, like \x1b, so what
+// is printed is exactly what will run.
+func quoteControlChars(command string) string {
+	var out strings.Builder
+	for _, r := range command {
+		if isControlChar(r) || r == '\n' || r == '\t' {
+			quoted := strconv.QuoteRune(r)
+			out.WriteString(quoted[1 : len(quoted)-1])
+			continue
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
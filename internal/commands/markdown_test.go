@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func renderMarkdown(text string, styled bool) string {
+	var out strings.Builder
+	renderer := &markdownRenderer{w: &out, styled: styled, indent: "  "}
+	renderer.Render(text)
+	return out.String()
+}
+
+func TestMarkdownRendererPlain(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{name: "top heading", markdown: "# Título", want: "  Título\n  ══════\n"},
+		{name: "second heading with code", markdown: "## Paso `uno` ##", want: "  Paso uno\n  ────────\n"},
+		{name: "minor heading", markdown: "### Notas", want: "  Notas\n"},
+		{
+			name:     "lists",
+			markdown: "- uno\n* dos\n1. tres\n  - anidado",
+			want:     "  • uno\n  • dos\n  1. tres\n    • anidado\n",
+		},
+		{
+			name:     "code block",
+			markdown: "Ejecuta:\n```bash\nls -la   # *todo*\n```\nY ya está",
+			want:     "  Ejecuta:\n    │ ls -la   # *todo*\n  Y ya está\n",
+		},
+		{
+			name:     "links",
+			markdown: "Lee [la guía](https://docs.example/guia) o [https://docs.example](https://docs.example)",
+			want:     "  Lee la guía (https://docs.example/guia) o https://docs.example\n",
+		},
+		{name: "emphasis", markdown: "**Importante**: usa _siempre_ *esto* y __aquello__", want: "  Importante: usa siempre esto y aquello\n"},
+		{name: "code span keeps markers", markdown: "Escribe `*no*` y `snake_case_name`", want: "  Escribe *no* y snake_case_name\n"},
+		{name: "block quote", markdown: "> Ojo con *esto*", want: "  ┃ Ojo con esto\n"},
+		{name: "horizontal rule", markdown: "- - -", want: "  " + strings.Repeat("─", 30) + "\n"},
+		{name: "blank lines collapsed", markdown: "uno\n\n\n\ndos", want: "  uno\n\n  dos\n"},
+		{name: "control characters dropped", markdown: "Hola\x1b[2J mundo\x07", want: "  Hola[2J mundo\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderMarkdown(tt.markdown, false); got != tt.want {
+				t.Errorf("Render(%q) =\n%q\nwant\n%q", tt.markdown, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownRendererStyled(t *testing.T) {
+	lines := strings.Split(renderMarkdown("## Usa `ls` y **mira**", true), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Render = %q, want a heading and its underline", lines)
+	}
+	for _, styled := range []string{styleBold + "Usa ", styleCyan + "ls" + styleReset, styleBold + "mira" + styleReset} {
+		if !strings.Contains(lines[0], styled) {
+			t.Errorf("heading %q does not contain %q", lines[0], styled)
+		}
+	}
+	// The underline matches the text shown, not the Markdown markers or the escape sequences.
+	if want := "  " + strings.Repeat("─", len("Usa ls y mira")); lines[1] != want {
+		t.Errorf("underline = %q, want %q", lines[1], want)
+	}
+
+	got := renderMarkdown("[guía](https://docs.example)", true)
+	if want := "  " + styleUnderline + "guía" + styleReset + " (https://docs.example)\n"; got != want {
+		t.Errorf("Render link = %q, want %q", got, want)
+	}
+}
+
+func TestStripControlChars(t *testing.T) {
+	got := stripControlChars("uno\tdos\r\ntres\x1b]0;titulo\x07\u009bcuatro")
+	if want := "uno\tdos\ntres]0;titulocuatro"; got != want {
+		t.Errorf("stripControlChars = %q, want %q", got, want)
+	}
+}
+
+func TestQuoteControlChars(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{command: "ls -la | grep 'año'", want: "ls -la | grep 'año'"},
+		{command: "echo \x1b[31mrojo", want: `echo \x1b[31mrojo`},
+		{command: "echo uno\necho dos", want: `echo uno\necho dos`},
+		{command: "printf 'a\tb'", want: `printf 'a\tb'`},
+		{command: "echo \u009b2J", want: `echo \u009b2J`},
+	}
+
+	for _, tt := range tests {
+		if got := quoteControlChars(tt.command); got != tt.want {
+			t.Errorf("quoteControlChars(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
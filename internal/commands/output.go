@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"unicode/utf8"
+)
+
+// cappedFile spills command output to a temporary file, keeping at most limit bytes and
+// counting the rest, so large outputs use neither memory nor unbounded disk space.
+type cappedFile struct {
+	file    *os.File
+	limit   int64
+	written int64
+	total   int64
+}
+
+func newCappedFile(limit int64) (*cappedFile, error) {
+	file, err := os.CreateTemp("", "missions-output-*")
+	if err != nil {
+		return nil, fmt.Errorf("no ha sido posible crear el archivo temporal para la salida: %w", err)
+	}
+	return &cappedFile{file: file, limit: limit}, nil
+}
+
+func (c *cappedFile) Write(p []byte) (int, error) {
+	c.total += int64(len(p))
+	if room := c.limit - c.written; room > 0 {
+		keep := p
+		if int64(len(keep)) > room {
+			keep = keep[:room]
+		}
+		n, err := c.file.Write(keep)
+		c.written += int64(n)
+		if err != nil {
+			return 0, err
+		}
+	}
+	// Report everything as written: the command must not fail because its output is discarded.
+	return len(p), nil
+}
+
+// Output returns the kept output, ending in a truncation marker if part of it was discarded.
+func (c *cappedFile) Output() (string, error) {
+	if _, err := c.file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(c.file)
+	if err != nil {
+		return "", err
+	}
+
+	if c.total <= c.written {
+		return string(data), nil
+	}
+
+	// Don't leave half a character before the marker.
+	for cut := 1; cut < utf8.UTFMax && cut <= len(data); cut++ {
+		if utf8.RuneStart(data[len(data)-cut]) {
+			if !utf8.FullRune(data[len(data)-cut:]) {
+				data = data[:len(data)-cut]
+			}
+			break
+		}
+	}
+	return string(data) + truncationMarker(int64(len(data)), c.total), nil
+}
+
+// Close removes the temporary file.
+func (c *cappedFile) Close() error {
+	closeErr := c.file.Close()
+	if err := os.Remove(c.file.Name()); err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// truncationMarker tells the reader of a result, including the server, that the output was cut.
+func truncationMarker(kept, total int64) string {
+	if kept == 0 {
+		return fmt.Sprintf("[missions: salida omitida, %d bytes; se ha alcanzado el límite de salida]\n", total)
+	}
+	return fmt.Sprintf("\n[missions: salida truncada, se han omitido %d de %d bytes]\n", total-kept, total)
+}
+
+// runCapturing runs cmd with stdout and stderr combined, keeping at most limit bytes of output.
+// runErr is the error of the command itself; err reports that the output could not be captured.
+func runCapturing(cmd *exec.Cmd, limit int64) (output string, runErr error, err error) {
+	capture, err := newCappedFile(limit)
+	if err != nil {
+		return "", nil, err
+	}
+	defer capture.Close()
+
+	cmd.Stdout = capture
+	cmd.Stderr = capture
+	runErr = cmd.Run()
+
+	output, err = capture.Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("no ha sido posible leer la salida del comando: %w", err)
+	}
+	return output, runErr, nil
+}
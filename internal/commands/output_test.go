@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+)
+
+// captureOutput writes the chunks to a capped file and returns what it keeps.
+func captureOutput(t *testing.T, limit int64, chunks ...string) string {
+	t.Helper()
+	capture, err := newCappedFile(limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := capture.file.Name()
+	defer func() {
+		if err := capture.Close(); err != nil {
+			t.Errorf("Close: %v", err)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("the temporary file %s was not removed", path)
+		}
+	}()
+
+	for _, chunk := range chunks {
+		if n, err := capture.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("Write(%q) = %d, %v, want the whole chunk reported as written", chunk, n, err)
+		}
+	}
+	output, err := capture.Output()
+	if err != nil {
+		t.Fatalf("Output: %v", err)
+	}
+	return output
+}
+
+func TestCappedFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		limit  int64
+		chunks []string
+		want   string
+	}{
+		{name: "within the limit", limit: 10, chunks: []string{"hola", "\n"}, want: "hola\n"},
+		{name: "exactly the limit", limit: 5, chunks: []string{"hola\n"}, want: "hola\n"},
+		{
+			name:   "over the limit",
+			limit:  6,
+			chunks: []string{"abc", "defgh", "ij"},
+			want:   "abcdef\n[missions: salida truncada, se han omitido 4 de 10 bytes]\n",
+		},
+		{
+			name:   "no room left",
+			limit:  0,
+			chunks: []string{"abc"},
+			want:   "[missions: salida omitida, 3 bytes; se ha alcanzado el límite de salida]\n",
+		},
+		{
+			name:   "cut inside a two byte character",
+			limit:  3,
+			chunks: []string{"aoñb"},
+			want:   "ao\n[missions: salida truncada, se han omitido 3 de 5 bytes]\n",
+		},
+		{
+			name:   "cut inside a four byte character",
+			limit:  3,
+			chunks: []string{"a🚀b"},
+			want:   "a\n[missions: salida truncada, se han omitido 5 de 6 bytes]\n",
+		},
+		{
+			name:   "cut after a whole character",
+			limit:  5,
+			chunks: []string{"a🚀b"},
+			want:   "a🚀\n[missions: salida truncada, se han omitido 1 de 6 bytes]\n",
+		},
+		{
+			name:   "only half a character kept",
+			limit:  1,
+			chunks: []string{"ñ"},
+			want:   "[missions: salida omitida, 2 bytes; se ha alcanzado el límite de salida]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := captureOutput(t, tt.limit, tt.chunks...); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// newTestExecutor returns an executor that runs commands with /bin/sh and the given output limits.
+func newTestExecutor(t *testing.T, maxCommand, maxTotal string) *CommandExecutor {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands of the test need a POSIX shell")
+	}
+	config.SkipProjectEnv()
+	t.Setenv("SHELL", "/bin/sh")
+	t.Setenv("MISSIONS_CLI_MAX_COMMAND_OUTPUT", maxCommand)
+	t.Setenv("MISSIONS_CLI_MAX_TOTAL_OUTPUT", maxTotal)
+	return NewCommandExecutor(config.NewConfig())
+}
+
+// printBytes is a command that prints n times the letter.
+func printBytes(n int, letter string) string {
+	return fmt.Sprintf("printf '%s'", strings.Repeat(letter, n))
+}
+
+func TestExecuteCommandPerCommandLimit(t *testing.T) {
+	executor := newTestExecutor(t, "8", "1K")
+
+	results, err := executor.ExecuteCommand([]string{printBytes(20, "a"), printBytes(5, "b")})
+	if err != nil {
+		t.Fatalf("ExecuteCommand: %v", err)
+	}
+	want := []string{
+		"aaaaaaaa\n[missions: salida truncada, se han omitido 12 de 20 bytes]\n",
+		"bbbbb",
+	}
+	if fmt.Sprint(results) != fmt.Sprint(want) {
+		t.Errorf("results = %q, want %q", results, want)
+	}
+}
+
+func TestExecuteCommandTotalLimit(t *testing.T) {
+	executor := newTestExecutor(t, "1K", "10")
+
+	results, err := executor.ExecuteCommand([]string{printBytes(6, "a"), printBytes(6, "b"), printBytes(6, "c")})
+	if err != nil {
+		t.Fatalf("ExecuteCommand: %v", err)
+	}
+	want := []string{
+		"aaaaaa",
+		"bbbb\n[missions: salida truncada, se han omitido 2 de 6 bytes]\n",
+		"[missions: salida omitida, 6 bytes; se ha alcanzado el límite de salida]\n",
+	}
+	if fmt.Sprint(results) != fmt.Sprint(want) {
+		t.Errorf("results = %q, want %q", results, want)
+	}
+}
+
+func TestExecuteCommandCapsFailedCommand(t *testing.T) {
+	executor := newTestExecutor(t, "4", "1K")
+
+	results, err := executor.ExecuteCommand([]string{printBytes(10, "e") + " >&2; exit 3", "echo never"})
+	if err != nil {
+		t.Fatalf("ExecuteCommand: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("results = %q, want only the failed command", results)
+	}
+	if !strings.Contains(results[0], "eeee\n[missions: salida truncada, se han omitido 6 de 10 bytes]") {
+		t.Errorf("result = %q, want the stderr output capped", results[0])
+	}
+}
@@ -0,0 +1,165 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eutika/eu-missions-cli/internal/auth"
+	"github.com/eutika/eu-missions-cli/internal/config"
+)
+
+func NewProfileCommand() *cobra.Command {
+	profileCmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Gestiona los perfiles de cuentas y servidores de Missions",
+		Long: "Cada perfil guarda sus propias credenciales y, opcionalmente, sus propios servidores. " +
+			"Usa --profile o la variable MISSIONS_PROFILE para elegir un perfil en un único comando",
+	}
+
+	profileCmd.AddCommand(
+		newProfileListCommand(),
+		newProfileUseCommand(),
+		newProfileDeleteCommand(),
+	)
+
+	return profileCmd
+}
+
+func newProfileListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "Muestra los perfiles disponibles",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			profiles, err := config.LoadProfiles()
+			if err != nil {
+				return fmt.Errorf("🚫 No ha sido posible leer los perfiles: %w", err)
+			}
+
+			active := config.ActiveProfile()
+			fmt.Println("\n👥 Perfiles de Missions")
+			fmt.Println("──────────────────────")
+			for _, name := range profiles.Names() {
+				marker := " "
+				if name == active {
+					marker = "*"
+				}
+
+				session := "sin sesión"
+				if auth.HasProfileSession(name) {
+					session = "con sesión"
+				}
+
+				server := profiles.Profiles[name].RemoteURL
+				if server == "" {
+					server = "servidor por defecto"
+				}
+
+				fmt.Printf("  %s %s (%s, %s)\n", marker, name, server, session)
+			}
+			fmt.Println()
+			return nil
+		},
+	}
+}
+
+func newProfileUseCommand() *cobra.Command {
+	var settings config.Profile
+
+	cmd := &cobra.Command{
+		Use:   "use [name]",
+		Short: "Selecciona el perfil activo, creándolo si no existe",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			name := args[0]
+			if err := config.ValidateProfileName(name); err != nil {
+				return err
+			}
+
+			profiles, err := config.LoadProfiles()
+			if err != nil {
+				return fmt.Errorf("🚫 No ha sido posible leer los perfiles: %w", err)
+			}
+
+			profile := profiles.Profiles[name]
+			mergeProfileSettings(&profile, settings)
+			profiles.Profiles[name] = profile
+			profiles.Current = name
+
+			if err := profiles.Save(); err != nil {
+				return fmt.Errorf("🚫 No ha sido posible guardar los perfiles: %w", err)
+			}
+
+			fmt.Printf("✅ Perfil activo: %s\n", name)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&settings.RemoteURL, "url", "", "URL de la API de Missions para este perfil")
+	cmd.Flags().StringVar(&settings.DeviceCodeURL, "device-code-url", "", "URL para solicitar el código de dispositivo")
+	cmd.Flags().StringVar(&settings.TokenURL, "token-url", "", "URL para obtener y refrescar tokens")
+	cmd.Flags().StringVar(&settings.RevocationURL, "revocation-url", "", "URL para revocar tokens")
+	cmd.Flags().StringVar(&settings.UserInfoURL, "userinfo-url", "", "URL para consultar el usuario autenticado")
+	cmd.Flags().StringVar(&settings.AuthorizationURL, "authorization-url", "",
+		"URL de autorización para 'missions login --web'")
+
+	return cmd
+}
+
+func newProfileDeleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete [name]",
+		Short: "Elimina un perfil y sus credenciales guardadas",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			name := args[0]
+			if name == config.DefaultProfile {
+				return errors.New("🚫 El perfil por defecto no se puede eliminar, usa 'missions logout' para cerrar su sesión")
+			}
+
+			profiles, err := config.LoadProfiles()
+			if err != nil {
+				return fmt.Errorf("🚫 No ha sido posible leer los perfiles: %w", err)
+			}
+			if _, exists := profiles.Profiles[name]; !exists {
+				return fmt.Errorf("🚫 No existe el perfil %s", name)
+			}
+
+			if err := auth.DeleteProfileTokens(name); err != nil {
+				return fmt.Errorf("🚫 No ha sido posible eliminar las credenciales del perfil %s: %w", name, err)
+			}
+
+			delete(profiles.Profiles, name)
+			if profiles.Current == name {
+				profiles.Current = ""
+			}
+			if err := profiles.Save(); err != nil {
+				return fmt.Errorf("🚫 No ha sido posible guardar los perfiles: %w", err)
+			}
+
+			fmt.Printf("🗑️  Perfil %s eliminado\n", name)
+			return nil
+		},
+	}
+}
+
+// mergeProfileSettings copies the endpoints given on the command line over the stored ones.
+func mergeProfileSettings(profile *config.Profile, settings config.Profile) {
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&profile.RemoteURL, settings.RemoteURL},
+		{&profile.DeviceCodeURL, settings.DeviceCodeURL},
+		{&profile.TokenURL, settings.TokenURL},
+		{&profile.RevocationURL, settings.RevocationURL},
+		{&profile.UserInfoURL, settings.UserInfoURL},
+		{&profile.AuthorizationURL, settings.AuthorizationURL},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+}
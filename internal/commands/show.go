@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eutika/eu-missions-cli/internal/services"
+)
+
+func NewShowCommand(remoteService *services.RemoteService) *cobra.Command {
+	return &cobra.Command{
+		Use:          "show [id]",
+		Short:        "Muestra el enunciado y los comandos de una etapa",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stage, err := remoteService.FetchStage(cmd.Context(), args[0])
+			if err != nil {
+				return fmt.Errorf("🚫 No ha sido posible recuperar la etapa: %w", err)
+			}
+			printStaleNotice(cmd, stage)
+
+			printStageHeader(stage)
+
+			fmt.Println("\n👀 Comandos que se ejecutarán:")
+			fmt.Println("────────────────────────────")
+			for _, command := range stage.Commands {
+				fmt.Printf("  ▶️  %s\n", quoteControlChars(command))
+			}
+			fmt.Println()
+			return nil
+		},
+	}
+}
+
+// printStageHeader shows the title, the description and the pass mark of a stage, so students
+// know what they are being evaluated on.
+func printStageHeader(stage *services.Stage) {
+	title := strings.TrimSpace(strings.ReplaceAll(stripControlChars(stage.Title), "\n", " "))
+	if title == "" {
+		title = "Etapa " + stage.ID
+	}
+
+	fmt.Printf("\n📘 %s\n", title)
+	fmt.Println(strings.Repeat("═", utf8.RuneCountInString(title)+3))
+
+	if description := strings.TrimSpace(stage.Description); description != "" {
+		fmt.Println()
+		newMarkdownRenderer(os.Stdout, "  ").Render(description)
+	}
+
+	if stage.RequiredCorrectPercentage > 0 {
+		fmt.Printf("\n🎯 Porcentaje de acierto requerido: %.0f%%\n", stage.RequiredCorrectPercentage)
+	}
+}
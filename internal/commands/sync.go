@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+	"github.com/eutika/eu-missions-cli/internal/services"
+)
+
+func NewSyncCommand(remoteService *services.RemoteService) *cobra.Command {
+	var list bool
+	var drop string
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Envía los resultados que no se pudieron enviar a Missions",
+		Long: "Cuando validate o submit no pueden contactar con Missions, los resultados se guardan en una cola local. " +
+			"Este comando los envía; también se envían solos en el siguiente comando que contacte con Missions",
+		Example: "  missions sync\n" +
+			"  missions sync --list\n" +
+			"  missions sync --drop 1a2b3c4d\n" +
+			"  missions sync --drop all",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			switch {
+			case list:
+				return listPendingSubmissions(remoteService, config.NewConfig().GetRemoteURL())
+			case drop != "":
+				return dropPendingSubmissions(remoteService, drop)
+			}
+
+			results, err := remoteService.Sync(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("🚫 %w", err)
+			}
+			if len(results) == 0 {
+				fmt.Println("✅ No hay resultados pendientes de enviar")
+				return nil
+			}
+
+			fmt.Println("\n📤 Enviando resultados pendientes:")
+			pending := false
+			for _, result := range results {
+				fmt.Printf("  %s\n", services.DescribeSyncResult(result))
+				if result.Err != nil {
+					pending = true
+				}
+			}
+			fmt.Println()
+
+			if pending {
+				return errors.New("🚫 Algunos resultados no se han podido enviar")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&list, "list", false, "Muestra los resultados pendientes sin enviarlos")
+	cmd.Flags().StringVar(&drop, "drop", "", "Descarta el resultado pendiente con ese identificador, o todos con 'all'")
+	cmd.MarkFlagsMutuallyExclusive("list", "drop")
+
+	return cmd
+}
+
+func listPendingSubmissions(remoteService *services.RemoteService, server string) error {
+	submissions, err := remoteService.PendingSubmissions()
+	if err != nil {
+		return fmt.Errorf("🚫 No ha sido posible leer la cola de resultados: %w", err)
+	}
+	if len(submissions) == 0 {
+		fmt.Println("✅ No hay resultados pendientes de enviar")
+		return nil
+	}
+
+	active := config.ActiveProfile()
+	fmt.Println("\n📥 Resultados pendientes de enviar")
+	fmt.Println("─────────────────────────────────")
+	for _, submission := range submissions {
+		fmt.Printf("  %s  %-8s etapa %s  %s", submission.ShortID(), submission.Command, submission.StageID,
+			submission.CreatedAt.Local().Format("2006-01-02 15:04"))
+		if submission.Profile != active {
+			fmt.Printf("  (perfil %s)", submission.Profile)
+		} else if !submission.IsFor(server) {
+			fmt.Printf("  (servidor %s)", submission.ServerURL())
+		}
+		fmt.Println()
+	}
+	fmt.Println()
+	return nil
+}
+
+func dropPendingSubmissions(remoteService *services.RemoteService, id string) error {
+	submissions, err := remoteService.PendingSubmissions()
+	if err != nil {
+		return fmt.Errorf("🚫 No ha sido posible leer la cola de resultados: %w", err)
+	}
+
+	if id != "all" {
+		submissions, err = matchPendingSubmission(submissions, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, submission := range submissions {
+		if dropErr := remoteService.DropSubmission(submission); dropErr != nil {
+			return fmt.Errorf("🚫 No ha sido posible descartar %s: %w", submission.ShortID(), dropErr)
+		}
+		fmt.Printf("🗑️  Descartado %s de la etapa %s\n", submission.Command, submission.StageID)
+	}
+
+	if len(submissions) == 0 {
+		return fmt.Errorf("🚫 No hay ningún resultado pendiente con el identificador %s", id)
+	}
+	return nil
+}
+
+// matchPendingSubmission returns the only queued submission whose identifier starts with id. Dropping
+// can't be undone, so an identifier that matches several submissions is an error.
+func matchPendingSubmission(submissions []*services.QueuedSubmission, id string) ([]*services.QueuedSubmission, error) {
+	var matches []*services.QueuedSubmission
+	for _, submission := range submissions {
+		if submission.IdempotencyKey == id {
+			return []*services.QueuedSubmission{submission}, nil
+		}
+		if strings.HasPrefix(submission.IdempotencyKey, id) {
+			matches = append(matches, submission)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("🚫 No hay ningún resultado pendiente con el identificador %s", id)
+	case 1:
+		return matches, nil
+	default:
+		ids := make([]string, 0, len(matches))
+		for _, match := range matches {
+			ids = append(ids, match.IdempotencyKey)
+		}
+		return nil, fmt.Errorf("🚫 El identificador %s corresponde a varios resultados pendientes (%s): "+
+			"escribe más caracteres", id, strings.Join(ids, ", "))
+	}
+}
+
+// printQueuedHint tells the student when results that could not be sent were kept for later.
+func printQueuedHint(cmd *cobra.Command, err error) {
+	var queued *services.QueuedSubmissionError
+	if errors.As(err, &queued) {
+		cmd.PrintErrf("📥 Los resultados se han guardado (%s) y se enviarán con 'missions sync' "+
+			"o en el próximo comando que contacte con Missions\n", queued.Submission.ShortID())
+	}
+}
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/eutika/eu-missions-cli/internal/services"
+)
+
+func TestMatchPendingSubmission(t *testing.T) {
+	submissions := []*services.QueuedSubmission{
+		{IdempotencyKey: "1a2b3c4d-0000"},
+		{IdempotencyKey: "1a2b9999-0000"},
+		{IdempotencyKey: "ffff0000-0000"},
+		{IdempotencyKey: "ffff0000-0000-extra"},
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		want    string
+		wantErr bool
+	}{
+		{name: "unique prefix", id: "1a2b3", want: "1a2b3c4d-0000"},
+		{name: "full key", id: "1a2b9999-0000", want: "1a2b9999-0000"},
+		{name: "full key that prefixes another", id: "ffff0000-0000", want: "ffff0000-0000"},
+		{name: "ambiguous prefix", id: "1a2b", wantErr: true},
+		{name: "ambiguous short prefix", id: "f", wantErr: true},
+		{name: "no match", id: "abcd", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := matchPendingSubmission(submissions, tt.id)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("matchPendingSubmission(%q) = %d matches, want an error", tt.id, len(matches))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("matchPendingSubmission(%q): %v", tt.id, err)
+			}
+			if len(matches) != 1 || matches[0].IdempotencyKey != tt.want {
+				t.Errorf("matchPendingSubmission(%q) = %v, want only %s", tt.id, matches, tt.want)
+			}
+		})
+	}
+}
@@ -14,45 +14,6 @@ type ValidateCommand struct {
 	executor      *CommandExecutor
 }
 
-func (vc *ValidateCommand) handleCommandResponse(cmd *cobra.Command, response map[string]interface{}) {
-	commands, ok := response["commands"].([]interface{})
-	if !ok {
-		cmd.PrintErrf("❌ Formato de respuesta inválido: el campo 'commands' no es un array\n")
-		os.Exit(1)
-	}
-
-	fmt.Println("\n📊 Detalle de comandos:")
-	fmt.Println("─────────────────────")
-	for _, cmdData := range commands {
-		if cmdMap, ok := cmdData.(map[string]interface{}); ok {
-			isCorrect := cmdMap["isCorrect"].(bool)
-			statusIcon := "✅"
-			if !isCorrect {
-				statusIcon = "❌"
-			}
-			fmt.Printf("  %s  %s\n", statusIcon, cmdMap["command"])
-		}
-	}
-
-	fmt.Println("\n🏁 Resultado final:")
-	fmt.Println("────────────────")
-
-	isValid := response["isValid"].(bool)
-	percentageCorrect := response["percentageCorrect"].(float64)
-	requiredPercentage := response["requiredCorrectPercentage"].(float64)
-
-	resultIcon := "🎉"
-	resultText := "VALIDACIÓN SUPERADA"
-	if !isValid {
-		resultIcon = "❌"
-		resultText = "VALIDACIÓN NO SUPERADA"
-	}
-
-	fmt.Printf("  %s %s\n", resultIcon, resultText)
-	fmt.Printf("  ➡️ Porcentaje de acierto: %.0f%% (requerido: %.0f%%)\n\n",
-		percentageCorrect, requiredPercentage)
-}
-
 func NewValidateCommand(remoteService *services.RemoteService, executor *CommandExecutor) *cobra.Command {
 	vc := &ValidateCommand{
 		remoteService: remoteService,
@@ -65,11 +26,14 @@ func NewValidateCommand(remoteService *services.RemoteService, executor *Command
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			// Fetch command from remote
-			commands, err := vc.remoteService.FetchCommands(args[0])
+			stage, err := vc.remoteService.FetchStage(cmd.Context(), args[0])
 			if err != nil {
 				cmd.PrintErrf("❌ Error la recuperar los comandos de la etapa: %v\n", err)
 				os.Exit(1)
 			}
+			printStaleNotice(cmd, stage)
+			printStageHeader(stage)
+			commands := stage.Commands
 
 			if len(commands) == 0 {
 				cmd.PrintErrf("❌ No se ha encontrado el comando de la etapa con id: %s\n", args[0])
@@ -97,14 +61,15 @@ func NewValidateCommand(remoteService *services.RemoteService, executor *Command
 			fmt.Println(output)
 
 			// Send result back to remote endpoint
-			response, sendErr := vc.remoteService.SendCommandResult("validate", args[0], output)
+			response, sendErr := vc.remoteService.SendCommandResult(cmd.Context(), "validate", args[0], output)
 			if sendErr != nil {
 				cmd.PrintErrf("❌ Error enviando resultado del comando: %v\n", sendErr)
+				printQueuedHint(cmd, sendErr)
 				os.Exit(1)
 			}
 
 			// Handle the command response
-			vc.handleCommandResponse(cmd, response)
+			printVerdict(response, "VALIDACIÓN SUPERADA", "VALIDACIÓN NO SUPERADA")
 		},
 	}
 }
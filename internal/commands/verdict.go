@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/eutika/eu-missions-cli/pkg/types"
+)
+
+// printVerdict shows the verdict of the server on every command and on the whole stage.
+func printVerdict(response *types.ValidationResponse, passedText, failedText string) {
+	fmt.Println("\n📊 Detalle de comandos:")
+	fmt.Println("─────────────────────")
+	for _, verdict := range response.Commands {
+		statusIcon := "✅"
+		if !verdict.IsCorrect {
+			statusIcon = "❌"
+		}
+		fmt.Printf("  %s  %s\n", statusIcon, verdict.Command)
+	}
+
+	fmt.Println("\n🏁 Resultado final:")
+	fmt.Println("────────────────")
+
+	resultIcon := "🎉"
+	resultText := passedText
+	if !response.IsValid {
+		resultIcon = "❌"
+		resultText = failedText
+	}
+
+	fmt.Printf("  %s %s\n", resultIcon, resultText)
+	fmt.Printf("  ➡️ Porcentaje de acierto: %.0f%% (requerido: %.0f%%)\n\n",
+		response.PercentageCorrect, response.RequiredCorrectPercentage)
+}
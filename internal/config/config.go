@@ -1,34 +1,79 @@
 package config
 
 import (
+	"math"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
-
-	"github.com/joho/godotenv"
+	"time"
 )
 
 type Config struct {
 	mu                sync.RWMutex
+	profile           string
 	keyringService    string
 	clientID          string
 	deviceCodeURL     string
 	tokenURL          string
+	revocationURL     string
+	userInfoURL       string
+	authorizationURL  string
 	remoteURL         string
 	dangerousPatterns []string
 }
 
+// processEnv is the environment the CLI was started with. Package variables are initialised before
+// main runs, so it never contains what LoadProjectEnv adds from the .env file of the current directory.
+var processEnv = environ()
+
+func environ() map[string]string {
+	env := make(map[string]string)
+	for _, entry := range os.Environ() {
+		if key, value, found := strings.Cut(entry, "="); found && key != "" {
+			env[key] = value
+		}
+	}
+	return env
+}
+
 func NewConfig() *Config {
 	return &Config{
-		keyringService: "missions-cli",
-		clientID:       "missions",
-		deviceCodeURL:  "https://missions.eutika.com/api/auth/device/code",
-		tokenURL:       "https://missions.eutika.com/api/auth/device/token",
+		keyringService:   "missions-cli",
+		clientID:         "missions",
+		deviceCodeURL:    "https://missions.eutika.com/api/auth/device/code",
+		tokenURL:         "https://missions.eutika.com/api/auth/device/token",
+		revocationURL:    "https://missions.eutika.com/api/auth/revoke",
+		userInfoURL:      "https://missions.eutika.com/api/auth/userinfo",
+		authorizationURL: "https://missions.eutika.com/api/auth/authorize",
+		remoteURL:        "https://missions.eutika.com/api/cli",
 		dangerousPatterns: []string{
 			"rm -rf", "sudo", "dd ", ":(){ :|:& };:", "mkfs", "format ",
 		},
 	}
 }
 
+// ForProfile returns a configuration pinned to the given profile instead of the active one.
+func (c *Config) ForProfile(name string) *Config {
+	cfg := NewConfig()
+	cfg.profile = name
+	return cfg
+}
+
+// profileSettings returns the endpoint overrides of the profile this configuration applies to.
+func (c *Config) profileSettings() Profile {
+	profile := c.profile
+	if profile == "" {
+		profile = ActiveProfile()
+	}
+
+	profiles, err := LoadProfiles()
+	if err != nil {
+		return Profile{}
+	}
+	return profiles.Profiles[profile]
+}
+
 func (c *Config) GetKeyringService() string {
 	return c.keyringService
 }
@@ -38,46 +83,294 @@ func (c *Config) GetClientID() string {
 }
 
 func (c *Config) GetDeviceCodeURL() string {
-	if err := godotenv.Load(); err != nil {
-
-	}
+	LoadProjectEnv()
 	// Check for environment variable override
 	if envURL := os.Getenv("MISSIONS_CLI_DEVICE_CODE_URL"); envURL != "" {
 		return envURL
 	}
+	if profileURL := c.profileSettings().DeviceCodeURL; profileURL != "" {
+		return profileURL
+	}
 	return c.deviceCodeURL
 }
 
 func (c *Config) GetTokenURL() string {
-	if err := godotenv.Load(); err != nil {
+	LoadProjectEnv()
+	// Check for environment variable override
+	if envURL := os.Getenv("MISSIONS_CLI_TOKEN_URL"); envURL != "" {
+		return envURL
+	}
+	if profileURL := c.profileSettings().TokenURL; profileURL != "" {
+		return profileURL
+	}
+	return c.tokenURL
+}
 
+func (c *Config) GetRevocationURL() string {
+	LoadProjectEnv()
+	// Check for environment variable override
+	if envURL := os.Getenv("MISSIONS_CLI_REVOCATION_URL"); envURL != "" {
+		return envURL
+	}
+	if profileURL := c.profileSettings().RevocationURL; profileURL != "" {
+		return profileURL
 	}
+	return c.revocationURL
+}
+
+func (c *Config) GetUserInfoURL() string {
+	LoadProjectEnv()
 	// Check for environment variable override
-	if envURL := os.Getenv("MISSIONS_CLI_TOKEN_URL"); envURL != "" {
+	if envURL := os.Getenv("MISSIONS_CLI_USERINFO_URL"); envURL != "" {
+		return envURL
+	}
+	if profileURL := c.profileSettings().UserInfoURL; profileURL != "" {
+		return profileURL
+	}
+	return c.userInfoURL
+}
 
+func (c *Config) GetAuthorizationURL() string {
+	LoadProjectEnv()
+	// Check for environment variable override
+	if envURL := os.Getenv("MISSIONS_CLI_AUTHORIZATION_URL"); envURL != "" {
 		return envURL
 	}
-	return c.tokenURL
+	if profileURL := c.profileSettings().AuthorizationURL; profileURL != "" {
+		return profileURL
+	}
+	return c.authorizationURL
 }
 
 func (c *Config) GetRemoteURL() string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	// Load .env file if possible, but don't fail if it doesn't exist
-	if err := godotenv.Load(); err != nil {
-
-	}
-
-	// Default URL
-	defaultURL := "https://missions.eutika.com/api/cli"
+	// Load the project .env file, if it is trusted
+	LoadProjectEnv()
 
 	// Check for environment variable, use it if set
 	if envURL := os.Getenv("MISSIONS_CLI_URL"); envURL != "" {
 		return envURL
 	}
 
-	return defaultURL
+	// Then the URL configured for the active profile
+	if profileURL := c.profileSettings().RemoteURL; profileURL != "" {
+		return profileURL
+	}
+
+	return c.remoteURL
+}
+
+// ConfiguredEndpoints returns the endpoints of the profile this configuration applies to, falling
+// back to the built-in defaults. Environment variables and .env files are ignored, so the result
+// only reflects what the user configured explicitly.
+func (c *Config) ConfiguredEndpoints() Profile {
+	endpoints := c.profileSettings()
+	defaults := []struct {
+		value    *string
+		fallback string
+	}{
+		{&endpoints.RemoteURL, c.remoteURL},
+		{&endpoints.DeviceCodeURL, c.deviceCodeURL},
+		{&endpoints.TokenURL, c.tokenURL},
+		{&endpoints.RevocationURL, c.revocationURL},
+		{&endpoints.UserInfoURL, c.userInfoURL},
+		{&endpoints.AuthorizationURL, c.authorizationURL},
+	}
+	for _, endpoint := range defaults {
+		if *endpoint.value == "" {
+			*endpoint.value = endpoint.fallback
+		}
+	}
+	return endpoints
+}
+
+// GetEnvironmentToken returns the access token given through MISSIONS_TOKEN, used in place of the
+// stored session by CI jobs and automated graders. It is read from the environment the CLI was
+// started with, never from a .env file.
+func (c *Config) GetEnvironmentToken() string {
+	return strings.TrimSpace(processEnv["MISSIONS_TOKEN"])
+}
+
+// GetRequestTimeout returns the time limit of each request to the Missions API.
+func (c *Config) GetRequestTimeout() time.Duration {
+	const defaultTimeout = 30 * time.Second
+	LoadProjectEnv()
+	if timeout, err := time.ParseDuration(os.Getenv("MISSIONS_CLI_TIMEOUT")); err == nil && timeout > 0 {
+		return timeout
+	}
+	return defaultTimeout
+}
+
+// GetMaxRetries returns how many times a failed request to the Missions API is retried.
+func (c *Config) GetMaxRetries() int {
+	const defaultRetries = 3
+	LoadProjectEnv()
+	if retries, err := strconv.Atoi(os.Getenv("MISSIONS_CLI_MAX_RETRIES")); err == nil && retries >= 0 {
+		return retries
+	}
+	return defaultRetries
+}
+
+// GetConnectTimeout returns the time limit to open a connection to Missions, TLS handshake included.
+func (c *Config) GetConnectTimeout() time.Duration {
+	const defaultTimeout = 10 * time.Second
+	LoadProjectEnv()
+	if timeout, err := time.ParseDuration(os.Getenv("MISSIONS_CLI_CONNECT_TIMEOUT")); err == nil && timeout > 0 {
+		return timeout
+	}
+	return defaultTimeout
+}
+
+// GetReadTimeout returns the time limit to wait for the response headers once a request has been sent.
+func (c *Config) GetReadTimeout() time.Duration {
+	const defaultTimeout = 20 * time.Second
+	LoadProjectEnv()
+	if timeout, err := time.ParseDuration(os.Getenv("MISSIONS_CLI_READ_TIMEOUT")); err == nil && timeout > 0 {
+		return timeout
+	}
+	return defaultTimeout
+}
+
+// GetProxy returns the proxy URL configured for the CLI, "none" to ignore the proxy environment
+// variables, or an empty string to use HTTPS_PROXY, HTTP_PROXY and NO_PROXY as usual.
+func (c *Config) GetProxy() string {
+	LoadProjectEnv()
+	return strings.TrimSpace(os.Getenv("MISSIONS_CLI_PROXY"))
+}
+
+// GetCABundle returns the path of a PEM file with certificate authorities trusted besides the system ones.
+func (c *Config) GetCABundle() string {
+	LoadProjectEnv()
+	return os.Getenv("MISSIONS_CLI_CA_BUNDLE")
+}
+
+// GetClientCertificate returns the PEM files of the client certificate and key used for mutual TLS.
+// The key defaults to the certificate file, for bundles holding both.
+func (c *Config) GetClientCertificate() (certFile, keyFile string) {
+	LoadProjectEnv()
+	certFile = os.Getenv("MISSIONS_CLI_CLIENT_CERT")
+	keyFile = os.Getenv("MISSIONS_CLI_CLIENT_KEY")
+	if keyFile == "" {
+		keyFile = certFile
+	}
+	return certFile, keyFile
+}
+
+// GetPinnedKeys returns the base64 SHA-256 hashes of the public keys (SPKI) accepted for the
+// official Missions host. An empty list disables pinning.
+func (c *Config) GetPinnedKeys() []string {
+	LoadProjectEnv()
+	var pins []string
+	for _, pin := range strings.Split(os.Getenv("MISSIONS_CLI_PINNED_KEYS"), ",") {
+		if pin = strings.TrimPrefix(strings.TrimSpace(pin), "sha256/"); pin != "" {
+			pins = append(pins, pin)
+		}
+	}
+	return pins
+}
+
+// GetCacheMaxAge returns for how long downloaded stage definitions are kept. Zero disables the cache.
+func (c *Config) GetCacheMaxAge() time.Duration {
+	const defaultMaxAge = 7 * 24 * time.Hour
+	LoadProjectEnv()
+	if maxAge, err := time.ParseDuration(os.Getenv("MISSIONS_CLI_CACHE_MAX_AGE")); err == nil && maxAge >= 0 {
+		return maxAge
+	}
+	return defaultMaxAge
+}
+
+// GetMaxCommandOutput returns how many bytes of the output of each stage command are kept and sent.
+func (c *Config) GetMaxCommandOutput() int64 {
+	const defaultLimit = 1 << 20
+	LoadProjectEnv()
+	if limit, ok := parseByteSize(os.Getenv("MISSIONS_CLI_MAX_COMMAND_OUTPUT")); ok {
+		return limit
+	}
+	return defaultLimit
+}
+
+// GetMaxTotalOutput returns how many bytes of output are kept and sent for all the commands of a stage.
+func (c *Config) GetMaxTotalOutput() int64 {
+	const defaultLimit = 4 << 20
+	LoadProjectEnv()
+	if limit, ok := parseByteSize(os.Getenv("MISSIONS_CLI_MAX_TOTAL_OUTPUT")); ok {
+		return limit
+	}
+	return defaultLimit
+}
+
+// byteSizeUnits are the suffixes accepted by parseByteSize, in upper case. K, M and G are powers of 1024.
+var byteSizeUnits = map[string]int64{
+	"": 1, "B": 1,
+	"K": 1 << 10, "KB": 1 << 10, "KIB": 1 << 10,
+	"M": 1 << 20, "MB": 1 << 20, "MIB": 1 << 20,
+	"G": 1 << 30, "GB": 1 << 30, "GIB": 1 << 30,
+}
+
+// parseByteSize reads a positive size in bytes, optionally followed by a B, K, M or G suffix,
+// like "512K", "1MiB" or "2MB".
+func parseByteSize(value string) (int64, bool) {
+	value = strings.TrimSpace(value)
+	digits := strings.IndexFunc(value, func(r rune) bool { return r < '0' || r > '9' })
+	if digits < 0 {
+		digits = len(value)
+	}
+
+	multiplier, ok := byteSizeUnits[strings.ToUpper(strings.TrimSpace(value[digits:]))]
+	if !ok {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(value[:digits], 10, 64)
+	if err != nil || size <= 0 || size > math.MaxInt64/multiplier {
+		return 0, false
+	}
+	return size * multiplier, true
+}
+
+// GetCredentialStore returns the configured credential store, or an empty string to pick one automatically.
+// It is read from the environment the CLI was started with, never from a .env file.
+func (c *Config) GetCredentialStore() string {
+	return processEnv["MISSIONS_CLI_CREDENTIAL_STORE"]
+}
+
+// GetCredentialHelper returns the external credential helper command. As it is run by the CLI, it
+// is read from the environment the CLI was started with, never from a .env file.
+func (c *Config) GetCredentialHelper() string {
+	return processEnv["MISSIONS_CLI_CREDENTIAL_HELPER"]
+}
+
+// Encryption modes of the fallback token file.
+const (
+	FileEncryptionMachine    = "machine"
+	FileEncryptionPassphrase = "passphrase"
+)
+
+// GetFileEncryption returns how the fallback token file key is derived: from the machine
+// identity (the default) or from a user passphrase.
+func (c *Config) GetFileEncryption() string {
+	LoadProjectEnv()
+	if mode := os.Getenv("MISSIONS_CLI_FILE_ENCRYPTION"); mode == FileEncryptionPassphrase {
+		return mode
+	}
+	return FileEncryptionMachine
+}
+
+// GetFilePassphrase returns the fallback token file passphrase given through the environment, if any.
+// It is read from the environment the CLI was started with, never from a .env file.
+func (c *Config) GetFilePassphrase() string {
+	return processEnv["MISSIONS_CLI_PASSPHRASE"]
+}
+
+// GetPassphraseCacheTTL returns for how long an unlocked token file key is remembered in a terminal session.
+func (c *Config) GetPassphraseCacheTTL() time.Duration {
+	const defaultTTL = 15 * time.Minute
+	LoadProjectEnv()
+	if ttl, err := time.ParseDuration(os.Getenv("MISSIONS_CLI_PASSPHRASE_CACHE_TTL")); err == nil {
+		return ttl
+	}
+	return defaultTTL
 }
 
 func (c *Config) GetDangerousPatterns() []string {
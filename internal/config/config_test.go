@@ -0,0 +1,71 @@
+package config
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   int64
+		wantOK bool
+	}{
+		{value: "1024", want: 1024, wantOK: true},
+		{value: "100B", want: 100, wantOK: true},
+		{value: "512K", want: 512 << 10, wantOK: true},
+		{value: "512k", want: 512 << 10, wantOK: true},
+		{value: "2KB", want: 2 << 10, wantOK: true},
+		{value: "1MiB", want: 1 << 20, wantOK: true},
+		{value: "1mib", want: 1 << 20, wantOK: true},
+		{value: "3MB", want: 3 << 20, wantOK: true},
+		{value: "2G", want: 2 << 30, wantOK: true},
+		{value: "1GiB", want: 1 << 30, wantOK: true},
+		{value: " 4 M ", want: 4 << 20, wantOK: true},
+		{value: ""},
+		{value: "B"},
+		{value: "K"},
+		{value: "KiB"},
+		{value: "1I"},
+		{value: "1IB"},
+		{value: "1BB"},
+		{value: "1T"},
+		{value: "1KK"},
+		{value: "1.5M"},
+		{value: "-1K"},
+		{value: "+1K"},
+		{value: "0"},
+		{value: "0K"},
+		{value: "1 000"},
+		{value: "M1"},
+		{value: "9223372036854775807", want: math.MaxInt64, wantOK: true},
+		{value: "9223372036854775807K"},
+		{value: "99999999999999999999"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseByteSize(tt.value)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseByteSize(%q) = %d, %v, want %d, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGetEnvironmentToken(t *testing.T) {
+	original := processEnv
+	t.Cleanup(func() { processEnv = original })
+
+	processEnv = map[string]string{"MISSIONS_TOKEN": " token\n"}
+	if got := NewConfig().GetEnvironmentToken(); got != "token" {
+		t.Errorf("GetEnvironmentToken() = %q, want %q", got, "token")
+	}
+
+	// Variables set after start-up, like those of a .env file, are not used.
+	processEnv = map[string]string{}
+	t.Setenv("MISSIONS_TOKEN", "from-dotenv")
+	if got := NewConfig().GetEnvironmentToken(); got != "" {
+		t.Errorf("GetEnvironmentToken() = %q, want the variable ignored unless it was set at start-up", got)
+	}
+}
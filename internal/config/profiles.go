@@ -0,0 +1,160 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// DefaultProfile is the profile used when none is selected.
+const DefaultProfile = "default"
+
+// Profile holds the per-profile endpoint overrides. Empty fields use the built-in defaults.
+type Profile struct {
+	RemoteURL        string `json:"remote_url,omitempty"`
+	DeviceCodeURL    string `json:"device_code_url,omitempty"`
+	TokenURL         string `json:"token_url,omitempty"`
+	RevocationURL    string `json:"revocation_url,omitempty"`
+	UserInfoURL      string `json:"userinfo_url,omitempty"`
+	AuthorizationURL string `json:"authorization_url,omitempty"`
+}
+
+// Profiles is the content of the profiles file in the user config directory.
+type Profiles struct {
+	Current  string             `json:"current,omitempty"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+var (
+	profileOverride   string
+	profileOverrideMu sync.RWMutex
+)
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidateProfileName checks that a profile name can be safely used as a storage namespace.
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use only letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// SetActiveProfile selects the profile for the rest of the process, taking precedence over
+// MISSIONS_PROFILE and the current profile stored on disk.
+func SetActiveProfile(name string) {
+	profileOverrideMu.Lock()
+	defer profileOverrideMu.Unlock()
+	profileOverride = name
+}
+
+// ActiveProfile returns the selected profile name.
+func ActiveProfile() string {
+	profileOverrideMu.RLock()
+	override := profileOverride
+	profileOverrideMu.RUnlock()
+
+	if override != "" {
+		return override
+	}
+	LoadProjectEnv()
+	if envProfile := os.Getenv("MISSIONS_PROFILE"); envProfile != "" {
+		return envProfile
+	}
+	if profiles, err := LoadProfiles(); err == nil && profiles.Current != "" {
+		return profiles.Current
+	}
+	return DefaultProfile
+}
+
+// GetConfigDir returns the user configuration directory of the CLI.
+func GetConfigDir() string {
+	var baseDir string
+
+	if runtime.GOOS == "windows" {
+		baseDir = os.Getenv("APPDATA")
+		if baseDir == "" {
+			baseDir = filepath.Join(os.Getenv("USERPROFILE"), "AppData", "Roaming")
+		}
+	} else {
+		// Unix-like systems (Linux, macOS)
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			homeDir = os.Getenv("HOME")
+		}
+		baseDir = filepath.Join(homeDir, ".config")
+	}
+
+	return filepath.Join(baseDir, "missions-cli")
+}
+
+func profilesPath() string {
+	return filepath.Join(GetConfigDir(), "profiles.json")
+}
+
+// LoadProfiles reads the profiles file. A missing file yields an empty set of profiles.
+func LoadProfiles() (*Profiles, error) {
+	profiles := &Profiles{Profiles: make(map[string]Profile)}
+
+	data, err := os.ReadFile(profilesPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return profiles, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, profiles); err != nil {
+		return nil, fmt.Errorf("failed to parse profiles file: %w", err)
+	}
+	if profiles.Profiles == nil {
+		profiles.Profiles = make(map[string]Profile)
+	}
+
+	return profiles, nil
+}
+
+// Save writes the profiles file.
+func (p *Profiles) Save() error {
+	if err := os.MkdirAll(GetConfigDir(), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(profilesPath(), data, 0600)
+}
+
+// Names returns every known profile name, including the default one, sorted.
+func (p *Profiles) Names() []string {
+	names := []string{DefaultProfile}
+	for name := range p.Profiles {
+		if name != DefaultProfile {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// EnsureProfile records a profile in the profiles file if it is not there yet.
+func EnsureProfile(name string) error {
+	profiles, err := LoadProfiles()
+	if err != nil {
+		return err
+	}
+	if _, exists := profiles.Profiles[name]; exists {
+		return nil
+	}
+
+	profiles.Profiles[name] = Profile{}
+	return profiles.Save()
+}
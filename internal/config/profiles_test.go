@@ -0,0 +1,117 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+// withProfiles runs the test with a fresh configuration directory holding profiles.
+func withProfiles(t *testing.T, profiles *Profiles) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	for _, key := range []string{"MISSIONS_PROFILE", "MISSIONS_CLI_URL", "MISSIONS_CLI_TOKEN_URL"} {
+		t.Setenv(key, "")
+	}
+	t.Cleanup(func() { SetActiveProfile("") })
+
+	if profiles != nil {
+		if err := profiles.Save(); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+}
+
+func TestValidateProfileName(t *testing.T) {
+	for _, name := range []string{"default", "work", "staging-eu", "ci_2", "A1"} {
+		if err := ValidateProfileName(name); err != nil {
+			t.Errorf("ValidateProfileName(%q) = %v, want nil", name, err)
+		}
+	}
+	for _, name := range []string{"", "work:main", "../work", "my profile", "perfil-ñ", "a/b"} {
+		if err := ValidateProfileName(name); err == nil {
+			t.Errorf("ValidateProfileName(%q) = nil, want an error", name)
+		}
+	}
+}
+
+func TestActiveProfile(t *testing.T) {
+	withProfiles(t, nil)
+	if got := ActiveProfile(); got != DefaultProfile {
+		t.Errorf("ActiveProfile() without profiles = %q, want %q", got, DefaultProfile)
+	}
+
+	withProfiles(t, &Profiles{Current: "work", Profiles: map[string]Profile{"work": {}}})
+	if got := ActiveProfile(); got != "work" {
+		t.Errorf("ActiveProfile() = %q, want the current profile %q", got, "work")
+	}
+
+	t.Setenv("MISSIONS_PROFILE", "staging")
+	if got := ActiveProfile(); got != "staging" {
+		t.Errorf("ActiveProfile() = %q, want MISSIONS_PROFILE over the current profile", got)
+	}
+
+	SetActiveProfile("home")
+	if got := ActiveProfile(); got != "home" {
+		t.Errorf("ActiveProfile() = %q, want --profile over MISSIONS_PROFILE", got)
+	}
+}
+
+func TestProfileEndpoints(t *testing.T) {
+	const stagingURL = "https://staging.missions.eutika.com/api/cli"
+	withProfiles(t, &Profiles{Profiles: map[string]Profile{
+		"staging": {RemoteURL: stagingURL, TokenURL: "https://staging.missions.eutika.com/api/auth/device/token"},
+	}})
+	defaults := NewConfig()
+
+	SetActiveProfile("staging")
+	cfg := NewConfig()
+	if got := cfg.GetRemoteURL(); got != stagingURL {
+		t.Errorf("GetRemoteURL() = %q, want the URL of the profile", got)
+	}
+	if got := cfg.GetTokenURL(); got != "https://staging.missions.eutika.com/api/auth/device/token" {
+		t.Errorf("GetTokenURL() = %q, want the URL of the profile", got)
+	}
+	if got := cfg.GetDeviceCodeURL(); got != defaults.deviceCodeURL {
+		t.Errorf("GetDeviceCodeURL() = %q, want the default for endpoints the profile does not set", got)
+	}
+
+	t.Setenv("MISSIONS_CLI_URL", "http://localhost:8080/api/cli")
+	if got := cfg.GetRemoteURL(); got != "http://localhost:8080/api/cli" {
+		t.Errorf("GetRemoteURL() = %q, want MISSIONS_CLI_URL over the profile", got)
+	}
+	if got := cfg.ConfiguredEndpoints().RemoteURL; got != stagingURL {
+		t.Errorf("ConfiguredEndpoints().RemoteURL = %q, want the profile URL without the environment", got)
+	}
+
+	if got := cfg.ForProfile(DefaultProfile).ConfiguredEndpoints().RemoteURL; got != defaults.remoteURL {
+		t.Errorf("ForProfile(default) RemoteURL = %q, want the default URL", got)
+	}
+}
+
+func TestProfilesNames(t *testing.T) {
+	profiles := &Profiles{Profiles: map[string]Profile{"work": {}, "home": {}, DefaultProfile: {}, "ci": {}}}
+	if got, want := profiles.Names(), []string{DefaultProfile, "ci", "home", "work"}; !slices.Equal(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+}
+
+func TestEnsureProfile(t *testing.T) {
+	withProfiles(t, &Profiles{Profiles: map[string]Profile{"staging": {RemoteURL: "https://staging.example/api/cli"}}})
+
+	for _, name := range []string{"work", "staging"} {
+		if err := EnsureProfile(name); err != nil {
+			t.Fatalf("EnsureProfile(%q): %v", name, err)
+		}
+	}
+
+	profiles, err := LoadProfiles()
+	if err != nil {
+		t.Fatalf("LoadProfiles: %v", err)
+	}
+	if _, ok := profiles.Profiles["work"]; !ok {
+		t.Error("EnsureProfile did not record the new profile")
+	}
+	if got := profiles.Profiles["staging"].RemoteURL; got != "https://staging.example/api/cli" {
+		t.Errorf("EnsureProfile replaced the settings of an existing profile: RemoteURL = %q", got)
+	}
+}
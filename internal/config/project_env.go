@@ -0,0 +1,281 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/joho/godotenv"
+	"golang.org/x/term"
+
+	"github.com/eutika/eu-missions-cli/internal/prompt"
+)
+
+// projectEnvFile is the project-level configuration file read from the current directory.
+const projectEnvFile = ".env"
+
+// projectEnvPrefix marks the variables that configure the CLI. A .env file of a lab repository
+// may only set them once the user trusts it, as they decide where the credentials are sent.
+const projectEnvPrefix = "MISSIONS_"
+
+// masked replaces the values of .env variables that are not CLI endpoints when they are shown.
+const masked = "****"
+
+var projectEnvLoaded atomic.Bool
+
+// ProjectEnv is the .env file of the current directory.
+type ProjectEnv struct {
+	Path string
+	Hash string
+	// Overrides holds the variables the file sets that need approval and are not already in the
+	// environment: those that configure the CLI and the sensitive ones (see isSensitiveEnv).
+	Overrides map[string]string
+	// Others holds the rest of the variables the file sets that are not already in the environment.
+	Others map[string]string
+}
+
+// trustedEnvs is the content of the file that remembers the approved .env files.
+type trustedEnvs struct {
+	Allowed map[string]string `json:"allowed"`
+}
+
+// LoadProjectEnv loads the .env file of the current directory into the environment, once per process.
+// Variables that configure the CLI or are sensitive are only applied if the file was approved, either
+// earlier or by answering the prompt now; otherwise they are ignored with a warning.
+func LoadProjectEnv() {
+	if !projectEnvLoaded.CompareAndSwap(false, true) {
+		return
+	}
+
+	env, err := ReadProjectEnv()
+	if err != nil || env == nil {
+		return
+	}
+	for key, value := range env.Others {
+		_ = os.Setenv(key, value)
+	}
+	if len(env.Overrides) == 0 {
+		return
+	}
+
+	if !env.Trusted() {
+		if !confirmProjectEnv(env) {
+			fmt.Fprintf(os.Stderr, "⚠️  Se ignoran las variables %s de %s porque no lo has aprobado.\n",
+				strings.Join(env.keys(), ", "), env.Path)
+			fmt.Fprintln(os.Stderr, "💡 Revisa el archivo y ejecuta 'missions env allow' si confías en él")
+			return
+		}
+		if allowErr := AllowProjectEnv(env); allowErr != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  No ha sido posible recordar la aprobación de %s: %v\n", env.Path, allowErr)
+		}
+	}
+
+	for key, value := range env.Overrides {
+		_ = os.Setenv(key, value)
+	}
+}
+
+// SkipProjectEnv keeps the .env file of the current directory out of the environment for the rest
+// of the process, for commands that inspect the file instead of using it.
+func SkipProjectEnv() {
+	projectEnvLoaded.Store(true)
+}
+
+// ReadProjectEnv reads the .env file of the current directory. It returns nil if there is none.
+func ReadProjectEnv() (*ProjectEnv, error) {
+	path, err := filepath.Abs(projectEnvFile)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 -- the project file is read on purpose.
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	values, err := godotenv.UnmarshalBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	sum := sha256.Sum256(data)
+	env := &ProjectEnv{
+		Path:      path,
+		Hash:      hex.EncodeToString(sum[:]),
+		Overrides: make(map[string]string),
+		Others:    make(map[string]string),
+	}
+	for key, value := range values {
+		// Like godotenv.Load, never replace what is already in the environment.
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		if strings.HasPrefix(key, projectEnvPrefix) || isSensitiveEnv(key) {
+			env.Overrides[key] = value
+		} else {
+			env.Others[key] = value
+		}
+	}
+
+	return env, nil
+}
+
+// isSensitiveEnv reports whether a variable outside the CLI configuration moves where the CLI keeps
+// its configuration and keys, or changes which programs run, what they load or where they connect.
+// Stage commands and requests to Missions inherit it, so it is only applied once the file is approved.
+func isSensitiveEnv(key string) bool {
+	key = strings.ToUpper(key)
+	switch key {
+	case "PATH", "SHELL", "BASH_ENV", "ENV", "ZDOTDIR", "HOME", "TMPDIR", "APPDATA", "LOCALAPPDATA":
+		return true
+	}
+	for _, prefix := range []string{"XDG_", "LD_", "DYLD_", "SSL_CERT_"} {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return strings.HasSuffix(key, "_PROXY")
+}
+
+// Trusted reports whether this exact content of the file was approved.
+func (e *ProjectEnv) Trusted() bool {
+	trusted, err := loadTrustedEnvs()
+	return err == nil && trusted.Allowed[e.Path] == e.Hash
+}
+
+// keys returns the names of the overridden variables, sorted.
+func (e *ProjectEnv) keys() []string {
+	keys := make([]string, 0, len(e.Overrides))
+	for key := range e.Overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// AllowProjectEnv remembers the current content of a .env file as trusted.
+func AllowProjectEnv(env *ProjectEnv) error {
+	trusted, err := loadTrustedEnvs()
+	if err != nil {
+		return err
+	}
+	trusted.Allowed[env.Path] = env.Hash
+	return trusted.save()
+}
+
+// DenyProjectEnv forgets the approval of the .env file at path. It reports whether it was approved.
+func DenyProjectEnv(path string) (bool, error) {
+	trusted, err := loadTrustedEnvs()
+	if err != nil {
+		return false, err
+	}
+	if _, ok := trusted.Allowed[path]; !ok {
+		return false, nil
+	}
+	delete(trusted.Allowed, path)
+	return true, trusted.save()
+}
+
+// PrintProjectEnvDiff shows how the .env file changes the configuration. Only the endpoints and
+// the profile of the CLI are shown with their values; the rest may hold passwords or API keys, so
+// their values are masked.
+func PrintProjectEnvDiff(w io.Writer, env *ProjectEnv) {
+	for _, key := range env.keys() {
+		current, shown := currentSetting(key)
+		if !shown {
+			fmt.Fprintf(w, "  %s=%s\n", key, masked)
+			continue
+		}
+		if current == "" {
+			current = "(sin definir)"
+		}
+		fmt.Fprintf(w, "  %s\n    - %s\n    + %s\n", key, current, env.Overrides[key])
+	}
+}
+
+// confirmProjectEnv shows the changes of an untrusted .env file and asks whether to apply them.
+// It never asks when nobody is at the terminal.
+func confirmProjectEnv(env *ProjectEnv) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stderr.Fd())) { // #nosec G115
+		return false
+	}
+
+	fmt.Fprintf(os.Stderr, "\n🔎 El archivo %s define variables de entorno para la CLI y los comandos que ejecuta:\n\n", env.Path)
+	PrintProjectEnvDiff(os.Stderr, env)
+	fmt.Fprintln(os.Stderr, "\n⚠️  Solo debes aprobarlo si confías en quien lo ha escrito: puede enviar tus credenciales a otro servidor o ejecutar otros programas.")
+
+	return prompt.Confirm(os.Stderr, "¿Confías en este archivo?", false)
+}
+
+// currentSetting returns the value an endpoint or the profile of the CLI has without the .env file.
+// It reports false for any other variable, whose values are not shown.
+func currentSetting(key string) (string, bool) {
+	cfg := NewConfig()
+	switch key {
+	case "MISSIONS_CLI_URL":
+		return cfg.GetRemoteURL(), true
+	case "MISSIONS_CLI_DEVICE_CODE_URL":
+		return cfg.GetDeviceCodeURL(), true
+	case "MISSIONS_CLI_TOKEN_URL":
+		return cfg.GetTokenURL(), true
+	case "MISSIONS_CLI_REVOCATION_URL":
+		return cfg.GetRevocationURL(), true
+	case "MISSIONS_CLI_USERINFO_URL":
+		return cfg.GetUserInfoURL(), true
+	case "MISSIONS_CLI_AUTHORIZATION_URL":
+		return cfg.GetAuthorizationURL(), true
+	case "MISSIONS_PROFILE":
+		return ActiveProfile(), true
+	default:
+		return "", false
+	}
+}
+
+func trustedEnvsPath() string {
+	return filepath.Join(GetConfigDir(), "trusted-env.json")
+}
+
+func loadTrustedEnvs() (*trustedEnvs, error) {
+	trusted := &trustedEnvs{Allowed: make(map[string]string)}
+
+	data, err := os.ReadFile(trustedEnvsPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return trusted, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, trusted); err != nil {
+		return nil, fmt.Errorf("failed to parse trusted .env file list: %w", err)
+	}
+	if trusted.Allowed == nil {
+		trusted.Allowed = make(map[string]string)
+	}
+
+	return trusted, nil
+}
+
+func (t *trustedEnvs) save() error {
+	if err := os.MkdirAll(GetConfigDir(), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(trustedEnvsPath(), data, 0600)
+}
@@ -0,0 +1,59 @@
+// Package prompt asks the user questions on the terminal.
+package prompt
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Confirm asks a yes or no question, writing it to w and reading the answer from the standard
+// input. An empty answer, just pressing Enter, picks defaultYes. When no answer can be read,
+// for example because the input has ended, it returns false.
+func Confirm(w io.Writer, question string, defaultYes bool) bool {
+	hint := "⚠️ Por favor, contesta 'sí' o 'no'."
+	if defaultYes {
+		hint += " También puedes pulsar 'Enter' para confirmar."
+	}
+
+	for {
+		fmt.Fprintf(w, "%s (si/no): ", question)
+
+		answer, err := readLine(os.Stdin)
+		if err != nil && answer == "" {
+			fmt.Fprintln(w)
+			return false
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "sí", "si", "s":
+			return true
+		case "no", "n":
+			return false
+		case "":
+			return defaultYes
+		default:
+			fmt.Fprintln(w, hint)
+		}
+	}
+}
+
+// readLine reads a line without its line break. It reads one byte at a time, so nothing past the
+// line is taken from the input that other readers, like password prompts, use afterwards.
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	var b [1]byte
+	for {
+		n, err := r.Read(b[:])
+		if n > 0 {
+			if b[0] == '\n' {
+				return strings.TrimSuffix(string(line), "\r"), nil
+			}
+			line = append(line, b[0])
+		}
+		if err != nil {
+			return string(line), err
+		}
+	}
+}
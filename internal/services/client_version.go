@@ -0,0 +1,55 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/eutika/eu-missions-cli/internal/version"
+)
+
+// Headers used to negotiate the version of the CLI with Missions.
+const (
+	clientVersionHeader      = "X-Missions-CLI-Version"
+	minVersionHeader         = "X-Missions-CLI-Min-Version"
+	recommendedVersionHeader = "X-Missions-CLI-Recommended-Version"
+)
+
+// upgradeURL explains how to install the latest version of the CLI.
+const upgradeURL = "https://github.com/eutika/eu-missions-cli#instalación"
+
+// UnsupportedVersionError is returned when Missions no longer accepts this version of the CLI.
+type UnsupportedVersionError struct {
+	Current string
+	// Minimum is the oldest version accepted by the server, if it advertised one.
+	Minimum string
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	if e.Minimum == "" {
+		return fmt.Sprintf("this version of the CLI (%s) is no longer supported by the server", e.Current)
+	}
+	return fmt.Sprintf("this version of the CLI (%s) is no longer supported by the server, the minimum is %s",
+		e.Current, e.Minimum)
+}
+
+// checkClientVersion stops with an upgrade message when the server requires a newer CLI, and
+// mentions a newer recommended version once per command without interrupting it.
+func (s *RemoteService) checkClientVersion(resp *http.Response) error {
+	current := version.Current()
+	minimum := resp.Header.Get(minVersionHeader)
+
+	tooOld := minimum != "" && !version.IsDev() && version.Compare(current, minimum) < 0
+	if tooOld || resp.StatusCode == http.StatusUpgradeRequired {
+		return fmt.Errorf("%w\n💡 Actualiza Missions CLI para seguir usándolo: %s",
+			&UnsupportedVersionError{Current: current, Minimum: minimum}, upgradeURL)
+	}
+
+	recommended := resp.Header.Get(recommendedVersionHeader)
+	if recommended != "" && !version.IsDev() && version.Compare(current, recommended) < 0 && !s.upgradeNoticeShown {
+		s.upgradeNoticeShown = true
+		fmt.Fprintf(os.Stderr, "📦 Hay una nueva versión de Missions CLI (%s, tienes la %s). Actualízala desde %s\n",
+			recommended, current, upgradeURL)
+	}
+	return nil
+}
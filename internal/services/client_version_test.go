@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/eutika/eu-missions-cli/internal/version"
+)
+
+// setVersion makes the running build report v for the rest of the test.
+func setVersion(t *testing.T, v string) {
+	t.Helper()
+	original := version.Version
+	version.Version = v
+	t.Cleanup(func() { version.Version = original })
+}
+
+func TestCheckClientVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		current     string
+		status      int
+		minimum     string
+		recommended string
+		wantErr     bool
+	}{
+		{name: "no requirements", current: "1.2.0"},
+		{name: "newer than the minimum", current: "1.3.0", minimum: "1.2.0"},
+		{name: "same as the minimum", current: "1.2.0", minimum: "1.2.0"},
+		{name: "older than the minimum", current: "1.1.9", minimum: "1.2.0", wantErr: true},
+		{name: "release candidate older than the minimum", current: "1.2.0-rc.9", minimum: "1.2.0-rc.10", wantErr: true},
+		{name: "release candidate before the minimum release", current: "1.2.0-rc.1", minimum: "1.2.0", wantErr: true},
+		{name: "upgrade required", current: "1.2.0", status: http.StatusUpgradeRequired, wantErr: true},
+		{name: "older than the recommended version", current: "1.2.0", recommended: "1.4.0"},
+		{name: "development build", current: "dev", minimum: "1.2.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setVersion(t, tt.current)
+			resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+			if tt.status != 0 {
+				resp.StatusCode = tt.status
+			}
+			if tt.minimum != "" {
+				resp.Header.Set(minVersionHeader, tt.minimum)
+			}
+			if tt.recommended != "" {
+				resp.Header.Set(recommendedVersionHeader, tt.recommended)
+			}
+
+			service := &RemoteService{}
+			err := service.checkClientVersion(resp)
+
+			var unsupported *UnsupportedVersionError
+			if got := errors.As(err, &unsupported); got != tt.wantErr {
+				t.Fatalf("checkClientVersion = %v, want an *UnsupportedVersionError: %v", err, tt.wantErr)
+			}
+			if tt.wantErr && (unsupported.Current != version.Current() || unsupported.Minimum != tt.minimum) {
+				t.Errorf("error = %+v, want the current and the minimum version", unsupported)
+			}
+			if got := service.upgradeNoticeShown; got != (tt.recommended != "") {
+				t.Errorf("upgradeNoticeShown = %v", got)
+			}
+		})
+	}
+}
+
+func TestRequestsCarryClientVersion(t *testing.T) {
+	setVersion(t, "v1.2.0")
+
+	var got string
+	service := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(clientVersionHeader)
+		w.Header().Set(minVersionHeader, "1.3.0")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"stage-1","title":"Primera","commands":[]}`))
+	}))
+
+	_, err := service.FetchStage(context.Background(), "stage-1")
+	if got != "1.2.0" {
+		t.Errorf("%s = %q, want %q", clientVersionHeader, got, "1.2.0")
+	}
+	var unsupported *UnsupportedVersionError
+	if !errors.As(err, &unsupported) {
+		t.Errorf("FetchStage = %v, want an *UnsupportedVersionError", err)
+	}
+}
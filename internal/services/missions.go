@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+
+	"github.com/eutika/eu-missions-cli/pkg/client"
+	"github.com/eutika/eu-missions-cli/pkg/types"
+)
+
+// ListMissions returns every mission of the student, going through all the pages.
+func (s *RemoteService) ListMissions(ctx context.Context) ([]types.Mission, error) {
+	var missions []types.Mission
+	err := s.call(ctx, func(api *client.Client) (err error) {
+		missions, err = api.ListMissions(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.flushQueue(ctx)
+	return missions, nil
+}
+
+// ListStages returns every stage of a mission together with the progress of the student.
+func (s *RemoteService) ListStages(ctx context.Context, missionID string) ([]types.StageSummary, error) {
+	var stages []types.StageSummary
+	err := s.call(ctx, func(api *client.Client) (err error) {
+		stages, err = api.ListStages(ctx, missionID)
+		return err
+	})
+	return stages, err
+}
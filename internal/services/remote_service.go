@@ -1,174 +1,291 @@
 package services
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"strings"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/term"
 
 	"github.com/eutika/eu-missions-cli/internal/auth"
 	"github.com/eutika/eu-missions-cli/internal/config"
+	"github.com/eutika/eu-missions-cli/internal/prompt"
+	"github.com/eutika/eu-missions-cli/internal/transport"
+	"github.com/eutika/eu-missions-cli/internal/version"
+	"github.com/eutika/eu-missions-cli/pkg/client"
 	"github.com/eutika/eu-missions-cli/pkg/types"
 )
 
 type RemoteService struct {
-	config *config.Config
-	client *http.Client
+	config      *config.Config
+	api         *client.Client
+	authService *auth.AuthService
+	queue       *SubmissionQueue
+	cache       *StageCache
+	// flushing is set while queued submissions are being sent, so they are not flushed again.
+	flushing bool
+	// upgradeNoticeShown is set once the user has been told about a recommended version.
+	upgradeNoticeShown bool
 }
 
-func NewRemoteService(cfg *config.Config) *RemoteService {
+func NewRemoteService(cfg *config.Config, authService *auth.AuthService) *RemoteService {
 	return &RemoteService{
-		config: cfg,
-		client: &http.Client{},
+		config:      cfg,
+		authService: authService,
+		queue:       NewSubmissionQueue(),
+		cache:       NewStageCache(cfg),
 	}
 }
 
-// createAuthenticatedRequest creates an HTTP request with authentication token.
-func (s *RemoteService) createAuthenticatedRequest(
-	ctx context.Context, method, url string, body io.Reader,
-) (*http.Request, error) {
-	req, err := http.NewRequestWithContext(ctx, method, url, body)
+// apiClient returns the client for the Missions API, creating it on first use so the settings
+// of the project .env file and of the selected profile are taken into account.
+func (s *RemoteService) apiClient() (*client.Client, error) {
+	if s.api == nil {
+		httpClient, err := transport.NewClient(s.config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure the HTTP client: %w", err)
+		}
+
+		s.api = s.newAPIClient(httpClient, client.TokenSourceFunc(sessionToken))
+	}
+	return s.api, nil
+}
+
+// newAPIClient creates a client for the configured server that identifies this version of the CLI
+// and retries transient failures.
+func (s *RemoteService) newAPIClient(httpClient *http.Client, tokens client.TokenSource) *client.Client {
+	return client.New(
+		client.WithBaseURL(s.config.GetRemoteURL()),
+		client.WithHTTPClient(httpClient),
+		client.WithTokenSource(tokens),
+		client.WithUserAgent(version.UserAgent()),
+		client.WithHeader(clientVersionHeader, version.Current()),
+		client.WithResponseHook(s.checkClientVersion),
+		client.WithRetry(s.withRetries),
+	)
+}
+
+// refreshSession renews the stored session with its refresh token. Tests replace it.
+var refreshSession = auth.RefreshToken
+
+// sessionToken returns the access token of the stored session, as long as it was issued for
+// the server of the request.
+func sessionToken(_ context.Context, requestURL string) (string, error) {
+	token, err := auth.GetTokenForURL(requestURL)
+	if auth.IsTokenOriginMismatch(err) {
+		return "", fmt.Errorf("%w\n💡 Missions está configurado para usar un servidor distinto del que emitió tu sesión "+
+			"(revisa MISSIONS_CLI_URL y el archivo .env del directorio actual). Si confías en ese servidor, "+
+			"inicia sesión en él con 'missions login'", err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("authentication token not found: %w", err)
 	}
+	return token, nil
+}
 
-	token, err := auth.GetCurrentToken()
+// call runs a request to the Missions API. Transient failures are retried by the client, one
+// HTTP request at a time. When the session turns out to be invalid it refreshes the token, or
+// offers an inline login on a terminal, and replays the request once.
+func (s *RemoteService) call(ctx context.Context, request func(api *client.Client) error) error {
+	api, err := s.apiClient()
 	if err != nil {
-		return nil, fmt.Errorf("authentication token not found: %w", err)
+		return err
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	if method == http.MethodPost {
-		req.Header.Set("Content-Type", "application/json")
+	err = request(api)
+	if err == nil || !isSessionError(err) {
+		return err
 	}
 
-	return req, nil
+	if recoverErr := s.recoverSession(ctx, err); recoverErr != nil {
+		return recoverErr
+	}
+
+	err = request(api)
+	if err != nil && isSessionError(err) {
+		return withLoginHint(err)
+	}
+	return err
 }
 
-// executeRequest executes an HTTP request and handles common response processing.
-func (s *RemoteService) executeRequest(req *http.Request) ([]byte, error) {
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+// isSessionError reports whether err can be solved by refreshing the token or logging in again.
+func isSessionError(err error) bool {
+	var unauthorized *client.UnauthorizedError
+	return errors.As(err, &unauthorized) || auth.IsLoginRequired(err)
+}
+
+// recoverSession tries to get a valid session back after err.
+func (s *RemoteService) recoverSession(ctx context.Context, err error) error {
+	// A token given through the environment can't be refreshed or replaced from here.
+	if auth.UsesEnvironmentToken() {
+		return fmt.Errorf("%w\n💡 Revisa el token de la variable MISSIONS_TOKEN", err)
 	}
-	defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-		body, errStatusCode := io.ReadAll(resp.Body)
-		if errStatusCode != nil {
-			return nil,
-				fmt.Errorf("request failed with status %d, could not read response body: %w", resp.StatusCode, errStatusCode)
+	// A token rejected by the server may still have a usable refresh token behind it.
+	var unauthorized *client.UnauthorizedError
+	if errors.As(err, &unauthorized) {
+		refreshErr := refreshSession()
+		if refreshErr == nil {
+			return nil
 		}
-
-		var headers strings.Builder
-		for key, values := range resp.Header {
-			headers.WriteString(fmt.Sprintf("%s: %s\n", key, strings.Join(values, ", ")))
+		if !auth.IsLoginRequired(refreshErr) {
+			return fmt.Errorf("%w; %w", err, refreshErr)
 		}
-
-		return nil, fmt.Errorf("request failed with status %d\nBody: %s\nHeaders:\n%s", resp.StatusCode, string(body), headers.String())
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	if s.authService == nil || !isInteractive() || !confirmInlineLogin() {
+		return withLoginHint(err)
 	}
 
-	return body, nil
+	// As in 'missions login', Ctrl-C or a termination signal stops the login cleanly.
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return s.authService.Login(ctx, auth.LoginOptions{})
 }
 
-// createCommandResultPayload creates a JSON payload for command results.
-func (s *RemoteService) createCommandResultPayload(id string, results []string) ([]byte, error) {
-	resultPayload := types.CommandResult{
-		ID:      id,
-		Results: results,
-	}
+// withLoginHint tells the user how to fix a session error by hand.
+func withLoginHint(err error) error {
+	return fmt.Errorf("%w\n💡 Ejecuta 'missions login' para iniciar sesión de nuevo", err)
+}
 
-	jsonPayload, err := json.Marshal(resultPayload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result payload: %w", err)
-	}
+// isInteractive reports whether the user can answer questions on the terminal.
+func isInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) // #nosec G115 -- file descriptors fit in an int.
+}
 
-	return jsonPayload, nil
+// confirmInlineLogin asks whether to log in now so the current command can continue.
+func confirmInlineLogin() bool {
+	fmt.Println("\n🔒 Tu sesión en Missions no es válida o ha caducado.")
+	return prompt.Confirm(os.Stdout, "¿Quieres iniciar sesión ahora para continuar?", true)
 }
 
-// unmarshalResponse unmarshals the response body into the provided interface.
-func (s *RemoteService) unmarshalResponse(data []byte, v interface{}) error {
-	if err := json.Unmarshal(data, v); err != nil {
-		return fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-	return nil
+// Stage is the definition of a stage as returned by FetchStage.
+type Stage struct {
+	types.Command
+	// Stale is set when Missions could not be reached and the definition comes from the local cache.
+	Stale bool
+	// FetchedAt is when the definition was downloaded or last revalidated.
+	FetchedAt time.Time
 }
 
-func (s *RemoteService) FetchCommands(id string) ([]string, error) {
-	url := fmt.Sprintf("%s/commands/%s", s.config.GetRemoteURL(), id)
-	req, err := s.createAuthenticatedRequest(context.Background(), http.MethodGet, url, nil)
+func (s *RemoteService) FetchCommands(ctx context.Context, id string) ([]string, error) {
+	stage, err := s.FetchStage(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	return stage.Commands, nil
+}
 
-	body, err := s.executeRequest(req)
-	if err != nil {
-		return nil, err
-	}
+// FetchStage returns the definition of a stage. Cached definitions are revalidated with
+// If-None-Match or If-Modified-Since, and used as they are when Missions can't be reached.
+func (s *RemoteService) FetchStage(ctx context.Context, id string) (*Stage, error) {
+	server := s.config.GetRemoteURL()
+	cached := s.cache.Get(server, id)
 
-	var cmd types.Command
-	if errUnmarshalFetch := s.unmarshalResponse(body, &cmd); err != nil {
-		return nil, errUnmarshalFetch
+	var opts []client.RequestOption
+	if cached != nil {
+		if cached.ETag != "" {
+			opts = append(opts, client.IfNoneMatch(cached.ETag))
+		}
+		if cached.LastModified != "" {
+			opts = append(opts, client.IfModifiedSince(cached.LastModified))
+		}
 	}
 
-	return cmd.Commands, nil
-}
-
-func (s *RemoteService) SendCommandResult(command string, id string, results []string) (map[string]interface{}, error) {
-	jsonPayload, err := s.createCommandResultPayload(id, results)
+	var resp *client.StageResponse
+	err := s.call(ctx, func(api *client.Client) (err error) {
+		resp, err = api.GetStage(ctx, id, opts...)
+		return err
+	})
 	if err != nil {
+		// Offline: a cached definition is still good enough to look at and to run.
+		if cached != nil && isRetryable(ctx, err) {
+			return &Stage{Command: cached.Command, Stale: true, FetchedAt: cached.FetchedAt}, nil
+		}
 		return nil, err
 	}
 
-	url := fmt.Sprintf("%s/%s", s.config.GetRemoteURL(), command)
-	req, err := s.createAuthenticatedRequest(context.Background(), http.MethodPost, url, bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		return nil, err
+	entry := cached
+	if !resp.NotModified {
+		entry = &cachedStage{Server: server, StageID: id, Command: resp.Command}
 	}
-
-	body, err := s.executeRequest(req)
-	if err != nil {
-		return nil, err
+	entry.ETag = firstNonEmpty(resp.ETag, entry.ETag)
+	entry.LastModified = firstNonEmpty(resp.LastModified, entry.LastModified)
+	entry.FetchedAt = time.Now().UTC()
+	if cacheErr := s.cache.Put(entry); cacheErr != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  No ha sido posible guardar la etapa en la caché: %v\n", cacheErr)
 	}
 
-	var response map[string]interface{}
-	errUnmarshalSend := s.unmarshalResponse(body, &response)
-	if errUnmarshalSend != nil {
-		return nil, errUnmarshalSend
-	}
+	s.flushQueue(ctx)
+	return &Stage{Command: entry.Command, FetchedAt: entry.FetchedAt}, nil
+}
 
-	return response, nil
+// ClearCache removes every cached stage definition.
+func (s *RemoteService) ClearCache() error {
+	return s.cache.Clear()
 }
 
-func (s *RemoteService) ValidateCommandResult(id string, results []string) (map[string]interface{}, error) {
-	jsonPayload, err := s.createCommandResultPayload(id, results)
-	if err != nil {
-		return nil, err
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
 	}
+	return ""
+}
 
-	url := fmt.Sprintf("%s/validate", s.config.GetRemoteURL())
-	req, err := s.createAuthenticatedRequest(context.Background(), http.MethodPost, url, bytes.NewBuffer(jsonPayload))
+// SendCommandResult sends the results of a stage to the given endpoint. If Missions can't be
+// reached, the results are stored in the submission queue and a *QueuedSubmissionError is returned.
+func (s *RemoteService) SendCommandResult(
+	ctx context.Context, command string, id string, results []string,
+) (*types.ValidationResponse, error) {
+	idempotencyKey, err := newIdempotencyKey()
 	if err != nil {
 		return nil, err
 	}
 
-	body, err := s.executeRequest(req)
-	if err != nil {
-		return nil, err
+	submission := &QueuedSubmission{
+		Command:        command,
+		StageID:        id,
+		Results:        results,
+		CreatedAt:      time.Now().UTC(),
+		IdempotencyKey: idempotencyKey,
+		Profile:        config.ActiveProfile(),
+		Server:         s.config.GetRemoteURL(),
 	}
 
-	var response map[string]interface{}
-	if errUnmarshalValidate := s.unmarshalResponse(body, &response); err != nil {
-		return nil, errUnmarshalValidate
+	response, err := s.sendSubmission(ctx, submission)
+	if err != nil {
+		return nil, s.queueSubmission(submission, err)
 	}
 
+	s.flushQueue(ctx)
 	return response, nil
 }
+
+func (s *RemoteService) ValidateCommandResult(ctx context.Context, id string, results []string) (*types.ValidationResponse, error) {
+	return s.SendCommandResult(ctx, "validate", id, results)
+}
+
+// sendSubmission sends the results of a stage with the submission's idempotency key.
+func (s *RemoteService) sendSubmission(ctx context.Context, submission *QueuedSubmission) (*types.ValidationResponse, error) {
+	var response *types.ValidationResponse
+	err := s.call(ctx, func(api *client.Client) (err error) {
+		idempotencyKey := client.WithIdempotencyKey(submission.IdempotencyKey)
+		switch submission.Command {
+		case "validate":
+			response, err = api.Validate(ctx, submission.StageID, submission.Results, idempotencyKey)
+		case "submit":
+			response, err = api.Submit(ctx, submission.StageID, submission.Results, idempotencyKey)
+		default:
+			err = fmt.Errorf("unknown submission command %q", submission.Command)
+		}
+		return err
+	})
+	return response, err
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eutika/eu-missions-cli/internal/auth"
+	"github.com/eutika/eu-missions-cli/internal/config"
+	"github.com/eutika/eu-missions-cli/pkg/client"
+)
+
+// testToken is the access token the services created by newTestService send.
+const testToken = "test-token"
+
+// newTestService returns a RemoteService for a stand-in Missions server, with its queue and cache
+// in a temporary home directory. Requests carry testToken instead of a stored session.
+func newTestService(t *testing.T, handler http.Handler) *RemoteService {
+	t.Helper()
+	return newTestServiceWithTokens(t, handler, client.StaticToken(testToken))
+}
+
+// newTestServiceWithTokens is like newTestService, with the access tokens supplied by tokens.
+func newTestServiceWithTokens(t *testing.T, handler http.Handler, tokens client.TokenSource) *RemoteService {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	t.Setenv("MISSIONS_CLI_URL", server.URL)
+
+	service := NewRemoteService(config.NewConfig(), nil)
+	service.api = service.newAPIClient(server.Client(), tokens)
+	return service
+}
+
+// shortenRetryDelays makes the backoff between retries negligible for the rest of the test.
+func shortenRetryDelays(t *testing.T) {
+	t.Helper()
+	base, maxDelay := retryBaseDelay, retryMaxDelay
+	retryBaseDelay, retryMaxDelay = time.Millisecond, 4*time.Millisecond
+	t.Cleanup(func() { retryBaseDelay, retryMaxDelay = base, maxDelay })
+}
+
+// sessionServer is a stand-in Missions server that only accepts the access token in valid.
+type sessionServer struct {
+	mu      sync.Mutex
+	valid   string
+	tokens  []string
+	current string
+}
+
+func (s *sessionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	s.tokens = append(s.tokens, token)
+
+	if token != s.valid {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="The access token expired"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"isValid":true,"percentageCorrect":100,"requiredCorrectPercentage":80,"commands":[]}`))
+}
+
+// Token hands out the access token the client currently holds.
+func (s *sessionServer) Token(context.Context, string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current, nil
+}
+
+func (s *sessionServer) sentTokens() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.tokens...)
+}
+
+// replaceRefreshSession makes refreshSession run refresh for the rest of the test, counting the calls.
+func replaceRefreshSession(t *testing.T, refresh func() error) *int {
+	t.Helper()
+	if auth.UsesEnvironmentToken() {
+		t.Skip("MISSIONS_TOKEN is set in the environment, so the session is never refreshed")
+	}
+	calls := 0
+	original := refreshSession
+	refreshSession = func() error {
+		calls++
+		return refresh()
+	}
+	t.Cleanup(func() { refreshSession = original })
+	return &calls
+}
+
+func TestCallRefreshesAndReplaysRequest(t *testing.T) {
+	server := &sessionServer{valid: "new-token", current: "expired-token"}
+	service := newTestServiceWithTokens(t, server, server)
+	calls := replaceRefreshSession(t, func() error {
+		server.mu.Lock()
+		server.current = "new-token"
+		server.mu.Unlock()
+		return nil
+	})
+
+	response, err := service.SendCommandResult(context.Background(), "validate", "stage-1", []string{"ok"})
+	if err != nil {
+		t.Fatalf("SendCommandResult: %v", err)
+	}
+	if !response.IsValid {
+		t.Errorf("response = %+v, want the verdict of the replayed request", response)
+	}
+	if *calls != 1 {
+		t.Errorf("refreshes = %d, want 1", *calls)
+	}
+	if got := server.sentTokens(); len(got) != 2 || got[0] != "expired-token" || got[1] != "new-token" {
+		t.Errorf("tokens sent = %q, want the expired token and then the refreshed one", got)
+	}
+}
+
+func TestCallFailsWhenSessionCannotBeRecovered(t *testing.T) {
+	tests := []struct {
+		name         string
+		refresh      func() error
+		wantRequests int
+		wantErr      string
+	}{
+		{
+			name:         "token still rejected after the refresh",
+			refresh:      func() error { return nil },
+			wantRequests: 2,
+			wantErr:      "missions login",
+		},
+		{
+			name:         "refresh token expired",
+			refresh:      func() error { return auth.NewSessionExpiredError(errors.New("invalid_grant")) },
+			wantRequests: 1,
+			wantErr:      "missions login",
+		},
+		{
+			name:         "refresh failed",
+			refresh:      func() error { return auth.NewTokenRefreshError(errors.New("connection refused")) },
+			wantRequests: 1,
+			wantErr:      auth.ErrTokenRefresh,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &sessionServer{valid: "new-token", current: "expired-token"}
+			service := newTestServiceWithTokens(t, server, server)
+			calls := replaceRefreshSession(t, tt.refresh)
+
+			_, err := service.FetchStage(context.Background(), "stage-1")
+			var unauthorized *client.UnauthorizedError
+			if !errors.As(err, &unauthorized) {
+				t.Fatalf("FetchStage = %v, want the 401 error", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("FetchStage = %v, want it to mention %q", err, tt.wantErr)
+			}
+			if *calls != 1 {
+				t.Errorf("refreshes = %d, want 1", *calls)
+			}
+			if got := len(server.sentTokens()); got != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
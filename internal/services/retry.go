@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/eutika/eu-missions-cli/pkg/client"
+)
+
+// Backoff between attempts: the delay doubles from retryBaseDelay up to retryMaxDelay, with jitter.
+// Tests shorten them.
+var (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 10 * time.Second
+)
+
+// maxRetryAfter is the longest Retry-After the CLI is willing to wait; longer ones end the command.
+const maxRetryAfter = 2 * time.Minute
+
+// withRetries runs attempt, retrying network errors, 5xx and 429 responses with exponential
+// backoff. Submissions carry the same Idempotency-Key on every attempt.
+func (s *RemoteService) withRetries(ctx context.Context, attempt func() error) error {
+	maxRetries := s.config.GetMaxRetries()
+
+	for retry := 0; ; retry++ {
+		err := attempt()
+		if err == nil || retry >= maxRetries || !isRetryable(ctx, err) {
+			return err
+		}
+
+		delay := backoffDelay(retry)
+		var statusErr *client.StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+			if statusErr.RetryAfter > maxRetryAfter {
+				return err
+			}
+			delay = statusErr.RetryAfter
+		}
+
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n🔁 Reintentando en %s (intento %d de %d)...\n",
+			err, delay.Round(100*time.Millisecond), retry+2, maxRetries+1)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// isRetryable reports whether a failed request may succeed if sent again.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var statusErr *client.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	// Dropped connections, failed dials and timeouts. Other errors, like invalid certificates,
+	// would fail again the same way.
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNREFUSED):
+		return true
+	case errors.As(err, &opErr), errors.As(err, &dnsErr):
+		return true
+	case errors.As(err, &netErr):
+		return netErr.Timeout()
+	default:
+		return false
+	}
+}
+
+// backoffDelay returns the delay before retry number attempt+1: a random value between half
+// and all of the exponential backoff, so clients that failed together don't retry together.
+func backoffDelay(attempt int) time.Duration {
+	delay := retryMaxDelay
+	if attempt < 16 {
+		delay = min(retryBaseDelay<<attempt, retryMaxDelay)
+	}
+
+	half := int64(delay / 2)
+	jitter, err := rand.Int(rand.Reader, big.NewInt(half+1))
+	if err != nil {
+		return delay
+	}
+	return time.Duration(half + jitter.Int64())
+}
+
+// newIdempotencyKey returns a random UUID (version 4) identifying one submission across retries.
+func newIdempotencyKey() (string, error) {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return "", fmt.Errorf("failed to create idempotency key: %w", err)
+	}
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16]), nil
+}
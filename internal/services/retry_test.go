@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+	"github.com/eutika/eu-missions-cli/pkg/client"
+)
+
+// timeoutError is a net.Error that reports whether it is a timeout.
+type timeoutError struct{ timeout bool }
+
+func (e timeoutError) Error() string   { return "i/o timeout" }
+func (e timeoutError) Timeout() bool   { return e.timeout }
+func (e timeoutError) Temporary() bool { return false }
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"too many requests", &client.StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"internal server error", &client.StatusError{StatusCode: http.StatusInternalServerError}, true},
+		{"service unavailable", fmt.Errorf("validate: %w", &client.StatusError{StatusCode: http.StatusServiceUnavailable}), true},
+		{"bad request", &client.StatusError{StatusCode: http.StatusBadRequest}, false},
+		{"not found", &client.StatusError{StatusCode: http.StatusNotFound}, false},
+		{"unauthorized", &client.UnauthorizedError{}, false},
+		{"connection closed", io.ErrUnexpectedEOF, true},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true},
+		{"unknown host", &net.DNSError{Err: "no such host", Name: "missions.eutika.com"}, true},
+		{"timeout", timeoutError{timeout: true}, true},
+		{"other network error", timeoutError{timeout: false}, false},
+		{"invalid certificate", x509.UnknownAuthorityError{}, false},
+		{"other error", errors.New("invalid stage"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(context.Background(), tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if isRetryable(ctx, io.ErrUnexpectedEOF) {
+			t.Error("isRetryable = true after the context was cancelled")
+		}
+	})
+}
+
+func TestBackoffDelay(t *testing.T) {
+	for attempt := 0; attempt < 40; attempt++ {
+		want := retryMaxDelay
+		if attempt < 16 {
+			want = min(retryBaseDelay<<attempt, retryMaxDelay)
+		}
+		for range 20 {
+			if got := backoffDelay(attempt); got < want/2 || got > want {
+				t.Fatalf("backoffDelay(%d) = %s, want between %s and %s", attempt, got, want/2, want)
+			}
+		}
+	}
+}
+
+func TestWithRetries(t *testing.T) {
+	unavailable := &client.StatusError{StatusCode: http.StatusServiceUnavailable}
+
+	tests := []struct {
+		name         string
+		errs         []error
+		wantErr      error
+		wantAttempts int
+	}{
+		{
+			name:         "success after transient failures",
+			errs:         []error{unavailable, &client.StatusError{StatusCode: http.StatusTooManyRequests}, nil},
+			wantAttempts: 3,
+		},
+		{
+			name:         "network error",
+			errs:         []error{io.ErrUnexpectedEOF, nil},
+			wantAttempts: 2,
+		},
+		{
+			name:         "gives up after the maximum retries",
+			errs:         []error{unavailable},
+			wantErr:      unavailable,
+			wantAttempts: 3,
+		},
+		{
+			name:         "rejected request",
+			errs:         []error{&client.StatusError{StatusCode: http.StatusUnprocessableEntity}},
+			wantErr:      &client.StatusError{},
+			wantAttempts: 1,
+		},
+		{
+			name:         "Retry-After longer than the limit",
+			errs:         []error{&client.StatusError{StatusCode: http.StatusServiceUnavailable, RetryAfter: maxRetryAfter + time.Second}},
+			wantErr:      &client.StatusError{},
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shortenRetryDelays(t)
+			t.Setenv("MISSIONS_CLI_MAX_RETRIES", "2")
+			service := &RemoteService{config: config.NewConfig()}
+
+			attempts := 0
+			err := service.withRetries(context.Background(), func() error {
+				attempts++
+				return tt.errs[min(attempts, len(tt.errs))-1]
+			})
+
+			var statusErr *client.StatusError
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.As(err, &statusErr) {
+				t.Errorf("withRetries = %v, want %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestWithRetriesWaitsForRetryAfter(t *testing.T) {
+	shortenRetryDelays(t)
+	service := &RemoteService{config: config.NewConfig()}
+	const retryAfter = 50 * time.Millisecond
+
+	var attempts []time.Time
+	err := service.withRetries(context.Background(), func() error {
+		attempts = append(attempts, time.Now())
+		if len(attempts) == 1 {
+			return &client.StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: retryAfter}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("withRetries: %v", err)
+	}
+	if len(attempts) != 2 {
+		t.Fatalf("attempts = %d, want 2", len(attempts))
+	}
+	if waited := attempts[1].Sub(attempts[0]); waited < retryAfter {
+		t.Errorf("waited %s before retrying, want at least the Retry-After of %s", waited, retryAfter)
+	}
+}
+
+func TestWithRetriesStopsWhenCancelled(t *testing.T) {
+	service := &RemoteService{config: config.NewConfig()}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	attempts := 0
+	err := service.withRetries(ctx, func() error {
+		attempts++
+		return &client.StatusError{StatusCode: http.StatusServiceUnavailable, RetryAfter: time.Minute}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("withRetries = %v, want context.Canceled", err)
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1", attempts)
+	}
+}
+
+func TestNewIdempotencyKey(t *testing.T) {
+	uuidV4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	seen := make(map[string]bool)
+	for range 100 {
+		key, err := newIdempotencyKey()
+		if err != nil {
+			t.Fatalf("newIdempotencyKey: %v", err)
+		}
+		if !uuidV4.MatchString(key) {
+			t.Fatalf("newIdempotencyKey() = %q, want a version 4 UUID", key)
+		}
+		if seen[key] {
+			t.Fatalf("newIdempotencyKey() returned %q twice", key)
+		}
+		seen[key] = true
+	}
+}
+
+func TestSubmissionRetriesKeepIdempotencyKey(t *testing.T) {
+	shortenRetryDelays(t)
+
+	var mu sync.Mutex
+	var keys []string
+	service := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		attempt := len(keys)
+		mu.Unlock()
+
+		switch attempt {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"isValid":true,"percentageCorrect":100,"requiredCorrectPercentage":80,"commands":[]}`))
+		}
+	}))
+
+	response, err := service.SendCommandResult(context.Background(), "submit", "stage-1", []string{"ok"})
+	if err != nil {
+		t.Fatalf("SendCommandResult: %v", err)
+	}
+	if !response.IsValid {
+		t.Errorf("response = %+v, want the verdict of the last attempt", response)
+	}
+
+	if len(keys) != 3 {
+		t.Fatalf("requests = %d, want 3", len(keys))
+	}
+	if keys[0] == "" || keys[1] != keys[0] || keys[2] != keys[0] {
+		t.Errorf("Idempotency-Key of each attempt = %q, want the same key on every attempt", keys)
+	}
+}
+
+func TestRejectedSubmissionIsNotRetried(t *testing.T) {
+	shortenRetryDelays(t)
+
+	requests := 0
+	service := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		http.Error(w, "invalid results", http.StatusUnprocessableEntity)
+	}))
+
+	_, err := service.SendCommandResult(context.Background(), "submit", "stage-1", []string{"ok"})
+	var statusErr *client.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("SendCommandResult = %v, want the 422 response", err)
+	}
+	var queued *QueuedSubmissionError
+	if errors.As(err, &queued) {
+		t.Error("a rejected submission was queued to be sent again")
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1", requests)
+	}
+}
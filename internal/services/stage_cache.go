@@ -0,0 +1,110 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+	"github.com/eutika/eu-missions-cli/pkg/types"
+)
+
+// cachedStage is a stage definition stored with the validators needed to revalidate it.
+type cachedStage struct {
+	Server       string        `json:"server"`
+	StageID      string        `json:"stageId"`
+	ETag         string        `json:"etag,omitempty"`
+	LastModified string        `json:"lastModified,omitempty"`
+	FetchedAt    time.Time     `json:"fetchedAt"`
+	Command      types.Command `json:"command"`
+}
+
+// StageCache keeps the stage definitions downloaded from Missions, one JSON file per server and stage.
+type StageCache struct {
+	dir    string
+	config *config.Config
+}
+
+// NewStageCache creates a cache whose entries expire after the configured maximum age.
+func NewStageCache(cfg *config.Config) *StageCache {
+	return &StageCache{
+		dir:    filepath.Join(config.GetConfigDir(), "cache", "stages"),
+		config: cfg,
+	}
+}
+
+// Enabled reports whether stage definitions are cached at all.
+func (c *StageCache) Enabled() bool {
+	return c.config.GetCacheMaxAge() > 0
+}
+
+func (c *StageCache) path(server, stageID string) string {
+	sum := sha256.Sum256([]byte(server + "\n" + stageID))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get returns the cached definition of a stage, or nil if there is none or it has expired.
+func (c *StageCache) Get(server, stageID string) *cachedStage {
+	if !c.Enabled() {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.path(server, stageID))
+	if err != nil {
+		return nil
+	}
+
+	var entry cachedStage
+	if json.Unmarshal(data, &entry) != nil || entry.Server != server || entry.StageID != stageID {
+		return nil
+	}
+	if time.Since(entry.FetchedAt) > c.config.GetCacheMaxAge() {
+		_ = os.Remove(c.path(server, stageID))
+		return nil
+	}
+
+	return &entry
+}
+
+// Put stores the definition of a stage.
+func (c *StageCache) Put(entry *cachedStage) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a concurrent reader never sees a partial entry.
+	tmp, err := os.CreateTemp(c.dir, ".stage-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(entry.Server, entry.StageID))
+}
+
+// Clear removes every cached stage definition.
+func (c *StageCache) Clear() error {
+	if err := os.RemoveAll(c.dir); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+	"github.com/eutika/eu-missions-cli/pkg/client"
+	"github.com/eutika/eu-missions-cli/pkg/types"
+)
+
+const (
+	testServer       = "https://missions.eutika.com/api/cli"
+	testLastModified = "Mon, 05 Oct 2026 10:00:00 GMT"
+)
+
+func newTestStageCache(t *testing.T) *StageCache {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	return NewStageCache(config.NewConfig())
+}
+
+func TestStageCacheRoundTrip(t *testing.T) {
+	cache := newTestStageCache(t)
+	entry := &cachedStage{
+		Server:    testServer,
+		StageID:   "stage-1",
+		ETag:      `"v1"`,
+		FetchedAt: time.Now().UTC(),
+		Command:   types.Command{ID: "stage-1", Title: "Primera", Commands: []string{"echo ok"}},
+	}
+	if err := cache.Put(entry); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	got := cache.Get(testServer, "stage-1")
+	if got == nil || got.ETag != entry.ETag || got.Command.Title != entry.Command.Title {
+		t.Fatalf("Get = %+v, want %+v", got, entry)
+	}
+	if got := cache.Get("https://evil.example/api/cli", "stage-1"); got != nil {
+		t.Errorf("Get for another server = %+v, want nil", got)
+	}
+	if got := cache.Get(testServer, "stage-2"); got != nil {
+		t.Errorf("Get for another stage = %+v, want nil", got)
+	}
+
+	if err := cache.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if got := cache.Get(testServer, "stage-1"); got != nil {
+		t.Errorf("Get after Clear = %+v, want nil", got)
+	}
+}
+
+func TestStageCacheExpiresEntries(t *testing.T) {
+	cache := newTestStageCache(t)
+	t.Setenv("MISSIONS_CLI_CACHE_MAX_AGE", "1h")
+
+	entry := &cachedStage{Server: testServer, StageID: "stage-1", FetchedAt: time.Now().Add(-2 * time.Hour)}
+	if err := cache.Put(entry); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if got := cache.Get(testServer, "stage-1"); got != nil {
+		t.Fatalf("Get = %+v, want nil for an expired entry", got)
+	}
+	if _, err := os.Stat(cache.path(testServer, "stage-1")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the expired entry was not removed: %v", err)
+	}
+}
+
+func TestStageCacheDisabled(t *testing.T) {
+	cache := newTestStageCache(t)
+	t.Setenv("MISSIONS_CLI_CACHE_MAX_AGE", "0")
+
+	if err := cache.Put(&cachedStage{Server: testServer, StageID: "stage-1", FetchedAt: time.Now()}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := os.Stat(cache.dir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the disabled cache created %s: %v", cache.dir, err)
+	}
+	if got := cache.Get(testServer, "stage-1"); got != nil {
+		t.Errorf("Get = %+v, want nil", got)
+	}
+}
+
+// stageServer is a stand-in Missions server for one stage whose replies the test can change.
+type stageServer struct {
+	mu       sync.Mutex
+	reply    http.HandlerFunc
+	requests []*http.Request
+}
+
+func (s *stageServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r)
+	reply := s.reply
+	s.mu.Unlock()
+	reply(w, r)
+}
+
+func (s *stageServer) setReply(reply http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reply = reply
+}
+
+func (s *stageServer) lastRequest() *http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[len(s.requests)-1]
+}
+
+func stageReply(etag, title string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", testLastModified)
+		_, _ = w.Write([]byte(`{"id":"stage-1","title":"` + title + `","commands":["echo ok"]}`))
+	}
+}
+
+func statusReply(status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}
+}
+
+// dropConnection closes the connection without answering, as when Missions can't be reached.
+func dropConnection(w http.ResponseWriter, _ *http.Request) {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err == nil {
+		_ = conn.Close()
+	}
+}
+
+// newStageServer returns a service whose cache holds the stage as first served by the server.
+func newStageServer(t *testing.T) (*stageServer, *RemoteService) {
+	t.Helper()
+	server := &stageServer{reply: stageReply(`"v1"`, "Primera")}
+	service := newTestService(t, server)
+
+	stage, err := service.FetchStage(context.Background(), "stage-1")
+	if err != nil {
+		t.Fatalf("FetchStage: %v", err)
+	}
+	if stage.Stale || stage.Title != "Primera" {
+		t.Fatalf("FetchStage = %+v, want the stage from the server", stage)
+	}
+	return server, service
+}
+
+func TestFetchStageRevalidatesCachedStage(t *testing.T) {
+	server, service := newStageServer(t)
+	first := service.cache.Get(service.config.GetRemoteURL(), "stage-1")
+	if first == nil {
+		t.Fatal("the stage was not cached")
+	}
+
+	server.setReply(statusReply(http.StatusNotModified))
+	stage, err := service.FetchStage(context.Background(), "stage-1")
+	if err != nil {
+		t.Fatalf("FetchStage: %v", err)
+	}
+
+	req := server.lastRequest()
+	if got := req.Header.Get("If-None-Match"); got != `"v1"` {
+		t.Errorf("If-None-Match = %q, want the cached ETag", got)
+	}
+	if got := req.Header.Get("If-Modified-Since"); got != testLastModified {
+		t.Errorf("If-Modified-Since = %q, want the cached Last-Modified", got)
+	}
+	if stage.Stale || stage.Title != "Primera" {
+		t.Errorf("FetchStage = %+v, want the cached stage, not stale", stage)
+	}
+	if stage.FetchedAt.Before(first.FetchedAt) {
+		t.Errorf("FetchedAt = %s, want it renewed after %s", stage.FetchedAt, first.FetchedAt)
+	}
+}
+
+func TestFetchStageReplacesChangedStage(t *testing.T) {
+	server, service := newStageServer(t)
+
+	server.setReply(stageReply(`"v2"`, "Segunda"))
+	stage, err := service.FetchStage(context.Background(), "stage-1")
+	if err != nil {
+		t.Fatalf("FetchStage: %v", err)
+	}
+	if stage.Title != "Segunda" {
+		t.Errorf("FetchStage title = %q, want the new definition", stage.Title)
+	}
+	if cached := service.cache.Get(service.config.GetRemoteURL(), "stage-1"); cached == nil || cached.ETag != `"v2"` {
+		t.Errorf("cached entry = %+v, want the new ETag", cached)
+	}
+}
+
+func TestFetchStageFallsBackToCache(t *testing.T) {
+	tests := []struct {
+		name      string
+		reply     http.HandlerFunc
+		status    int
+		wantStale bool
+	}{
+		{name: "connection dropped", reply: dropConnection, wantStale: true},
+		{name: "server unavailable", status: http.StatusServiceUnavailable, wantStale: true},
+		{name: "too many requests", status: http.StatusTooManyRequests, wantStale: true},
+		{name: "stage not found", status: http.StatusNotFound},
+		{name: "forbidden", status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, service := newStageServer(t)
+			t.Setenv("MISSIONS_CLI_MAX_RETRIES", "0")
+
+			reply := tt.reply
+			if reply == nil {
+				reply = statusReply(tt.status)
+			}
+			server.setReply(reply)
+			stage, err := service.FetchStage(context.Background(), "stage-1")
+
+			if !tt.wantStale {
+				var statusErr *client.StatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.status {
+					t.Errorf("FetchStage = %+v, %v, want the %d error instead of the cached stage", stage, err, tt.status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchStage: %v", err)
+			}
+			if !stage.Stale || stage.Title != "Primera" || stage.FetchedAt.IsZero() {
+				t.Errorf("FetchStage = %+v, want the cached stage marked as stale", stage)
+			}
+		})
+	}
+}
+
+func TestFetchStageWithoutCacheWhenUnavailable(t *testing.T) {
+	t.Setenv("MISSIONS_CLI_MAX_RETRIES", "0")
+	service := newTestService(t, statusReply(http.StatusServiceUnavailable))
+
+	stage, err := service.FetchStage(context.Background(), "stage-1")
+	var statusErr *client.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("FetchStage = %+v, %v, want the 503 error", stage, err)
+	}
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+)
+
+// QueuedSubmission is a result that could not be sent to Missions and waits in the local queue.
+type QueuedSubmission struct {
+	// Command is the endpoint the results go to: "submit" or "validate".
+	Command        string    `json:"command"`
+	StageID        string    `json:"stageId"`
+	Results        []string  `json:"results"`
+	CreatedAt      time.Time `json:"createdAt"`
+	IdempotencyKey string    `json:"idempotencyKey"`
+	Profile        string    `json:"profile"`
+	// Server is the Missions API URL the results were meant for. They are never sent anywhere else.
+	Server string `json:"server"`
+}
+
+// ServerURL returns the Missions API URL the submission is bound to. Submissions queued before the
+// server was recorded are bound to the server configured in their profile, never to overrides.
+func (q *QueuedSubmission) ServerURL() string {
+	if q.Server != "" {
+		return q.Server
+	}
+	return config.NewConfig().ForProfile(q.Profile).ConfiguredEndpoints().RemoteURL
+}
+
+// ShortID returns the abbreviated idempotency key used to refer to the submission.
+func (q *QueuedSubmission) ShortID() string {
+	const shortIDLength = 8
+	if len(q.IdempotencyKey) <= shortIDLength {
+		return q.IdempotencyKey
+	}
+	return q.IdempotencyKey[:shortIDLength]
+}
+
+// IsFor reports whether the submission is meant for the Missions API at server.
+func (q *QueuedSubmission) IsFor(server string) bool {
+	return strings.TrimRight(q.ServerURL(), "/") == strings.TrimRight(server, "/")
+}
+
+// ServerMismatchError is reported for a queued submission meant for another Missions server than
+// the one the CLI uses now. The submission stays in the queue and is not sent.
+type ServerMismatchError struct {
+	Queued  string
+	Current string
+}
+
+func (e *ServerMismatchError) Error() string {
+	return fmt.Sprintf("the results were saved for %s but the CLI now uses %s", e.Queued, e.Current)
+}
+
+// QueuedSubmissionError is returned when results could not be sent and were stored in the queue instead.
+type QueuedSubmissionError struct {
+	Submission *QueuedSubmission
+	Err        error
+}
+
+func (e *QueuedSubmissionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *QueuedSubmissionError) Unwrap() error {
+	return e.Err
+}
+
+// SubmissionQueue stores pending submissions as one JSON file each in the user config directory.
+type SubmissionQueue struct {
+	dir string
+}
+
+func NewSubmissionQueue() *SubmissionQueue {
+	return &SubmissionQueue{dir: filepath.Join(config.GetConfigDir(), "queue")}
+}
+
+func (q *SubmissionQueue) path(idempotencyKey string) string {
+	return filepath.Join(q.dir, idempotencyKey+".json")
+}
+
+// Add stores a submission in the queue.
+func (q *SubmissionQueue) Add(submission *QueuedSubmission) error {
+	if err := os.MkdirAll(q.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create queue directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(submission, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves a half written submission behind.
+	tmp, err := os.CreateTemp(q.dir, ".pending-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), q.path(submission.IdempotencyKey))
+}
+
+// List returns the queued submissions, oldest first. Unreadable files are skipped.
+func (q *SubmissionQueue) List() ([]*QueuedSubmission, error) {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var submissions []*QueuedSubmission
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, readErr := os.ReadFile(filepath.Join(q.dir, entry.Name()))
+		if readErr != nil {
+			continue
+		}
+		var submission QueuedSubmission
+		if json.Unmarshal(data, &submission) != nil || submission.IdempotencyKey == "" {
+			continue
+		}
+		submissions = append(submissions, &submission)
+	}
+
+	sort.Slice(submissions, func(i, j int) bool {
+		return submissions[i].CreatedAt.Before(submissions[j].CreatedAt)
+	})
+	return submissions, nil
+}
+
+// Remove deletes a submission from the queue.
+func (q *SubmissionQueue) Remove(submission *QueuedSubmission) error {
+	if err := os.Remove(q.path(submission.IdempotencyKey)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
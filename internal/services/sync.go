@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+	"github.com/eutika/eu-missions-cli/pkg/types"
+)
+
+// SyncResult is the outcome of sending one queued submission.
+type SyncResult struct {
+	Submission *QueuedSubmission
+	Response   *types.ValidationResponse
+	Err        error
+}
+
+// PendingSubmissions returns the queued submissions of every profile, oldest first.
+func (s *RemoteService) PendingSubmissions() ([]*QueuedSubmission, error) {
+	return s.queue.List()
+}
+
+// DropSubmission removes a queued submission without sending it.
+func (s *RemoteService) DropSubmission(submission *QueuedSubmission) error {
+	return s.queue.Remove(submission)
+}
+
+// Sync sends the queued submissions of the active profile, oldest first. Sent submissions and
+// submissions the server rejects are removed from the queue; the others stay for a later attempt.
+// Sending stops at the first submission that can't reach Missions. Submissions meant for another
+// server than the configured one are reported with a *ServerMismatchError and kept, unsent.
+func (s *RemoteService) Sync(ctx context.Context) ([]SyncResult, error) {
+	submissions, err := s.queue.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the submission queue: %w", err)
+	}
+
+	s.flushing = true
+	defer func() { s.flushing = false }()
+
+	profile := config.ActiveProfile()
+	server := s.config.GetRemoteURL()
+	var results []SyncResult
+	for _, submission := range submissions {
+		if submission.Profile != profile {
+			continue
+		}
+		// The results, and the session sent with them, only go to the server they were meant for.
+		if !submission.IsFor(server) {
+			results = append(results, SyncResult{
+				Submission: submission,
+				Err:        &ServerMismatchError{Queued: submission.ServerURL(), Current: server},
+			})
+			continue
+		}
+
+		response, sendErr := s.sendSubmission(ctx, submission)
+		results = append(results, SyncResult{Submission: submission, Response: response, Err: sendErr})
+		if sendErr != nil && isQueueable(sendErr) {
+			break
+		}
+		if removeErr := s.queue.Remove(submission); removeErr != nil {
+			return results, fmt.Errorf("failed to remove a sent submission from the queue: %w", removeErr)
+		}
+	}
+
+	return results, nil
+}
+
+// queueSubmission stores a submission that failed to be sent, if a later attempt may succeed.
+func (s *RemoteService) queueSubmission(submission *QueuedSubmission, err error) error {
+	if !isQueueable(err) {
+		return err
+	}
+	if queueErr := s.queue.Add(submission); queueErr != nil {
+		return fmt.Errorf("%w (the results could not be saved for later either: %w)", err, queueErr)
+	}
+	return &QueuedSubmissionError{Submission: submission, Err: err}
+}
+
+// isQueueable reports whether a failed submission may succeed later: Missions was unreachable,
+// overloaded, the session needs a new login, the CLI needs an upgrade or another server is
+// configured for now. Rejections of the submission itself are final.
+func isQueueable(err error) bool {
+	var unsupported *UnsupportedVersionError
+	var mismatch *ServerMismatchError
+	return isSessionError(err) || isRetryable(context.Background(), err) || errors.As(err, &unsupported) ||
+		errors.As(err, &mismatch)
+}
+
+// flushQueue sends the pending submissions after a request reached Missions, reporting the outcome briefly.
+func (s *RemoteService) flushQueue(ctx context.Context) {
+	if s.flushing {
+		return
+	}
+	submissions, err := s.queue.List()
+	if err != nil || len(submissions) == 0 {
+		return
+	}
+
+	results, err := s.Sync(ctx)
+	// Submissions for another server stay quietly in the queue; 'missions sync' reports them.
+	var sent []SyncResult
+	for _, result := range results {
+		var mismatch *ServerMismatchError
+		if !errors.As(result.Err, &mismatch) {
+			sent = append(sent, result)
+		}
+	}
+	if len(sent) > 0 {
+		fmt.Fprintf(os.Stderr, "\n📤 Enviando resultados pendientes:\n")
+	}
+	for _, result := range sent {
+		fmt.Fprintf(os.Stderr, "  %s\n", DescribeSyncResult(result))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+	}
+}
+
+// DescribeSyncResult summarises the outcome of sending a queued submission in one line.
+func DescribeSyncResult(result SyncResult) string {
+	submission := result.Submission
+	prefix := fmt.Sprintf("%s %s de la etapa %s (%s)", submission.ShortID(), submission.Command, submission.StageID,
+		submission.CreatedAt.Local().Format("2006-01-02 15:04"))
+
+	var mismatch *ServerMismatchError
+	switch {
+	case errors.As(result.Err, &mismatch):
+		return fmt.Sprintf("⏸️  %s: no se envía, se guardó para %s y ahora se usa %s", prefix, mismatch.Queued, mismatch.Current)
+	case result.Err != nil && isQueueable(result.Err):
+		return fmt.Sprintf("⏸️  %s: sigue pendiente, %v", prefix, result.Err)
+	case result.Err != nil:
+		return fmt.Sprintf("🚫 %s: rechazado y descartado, %v", prefix, result.Err)
+	case result.Response.IsValid:
+		return fmt.Sprintf("🎉 %s: superada con un %.0f%% de acierto", prefix, result.Response.PercentageCorrect)
+	default:
+		return fmt.Sprintf("❌ %s: no superada, %.0f%% de acierto (requerido: %.0f%%)", prefix,
+			result.Response.PercentageCorrect, result.Response.RequiredCorrectPercentage)
+	}
+}
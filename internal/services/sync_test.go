@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+)
+
+func TestSyncKeepsSubmissionsForAnotherServer(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("MISSIONS_CLI_URL", "https://evil.example/api/cli")
+	service := NewRemoteService(config.NewConfig(), nil)
+
+	submissions := []*QueuedSubmission{
+		{
+			Command: "submit", StageID: "1", Results: []string{"ok"}, CreatedAt: time.Now(),
+			IdempotencyKey: "aaaa1111", Profile: config.DefaultProfile, Server: "https://missions.eutika.com/api/cli",
+		},
+		// Queued before the server was recorded: bound to the server configured in the profile.
+		{
+			Command: "validate", StageID: "2", Results: []string{"ok"}, CreatedAt: time.Now().Add(time.Second),
+			IdempotencyKey: "bbbb2222", Profile: config.DefaultProfile,
+		},
+	}
+	for _, submission := range submissions {
+		if err := service.queue.Add(submission); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	results, err := service.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if len(results) != len(submissions) {
+		t.Fatalf("Sync returned %d results, want %d", len(results), len(submissions))
+	}
+	for _, result := range results {
+		var mismatch *ServerMismatchError
+		if !errors.As(result.Err, &mismatch) {
+			t.Errorf("result for %s error = %v, want a *ServerMismatchError", result.Submission.StageID, result.Err)
+			continue
+		}
+		if mismatch.Queued != "https://missions.eutika.com/api/cli" || mismatch.Current != "https://evil.example/api/cli" {
+			t.Errorf("mismatch = %+v, want the queued and the configured server", mismatch)
+		}
+	}
+
+	pending, err := service.PendingSubmissions()
+	if err != nil {
+		t.Fatalf("PendingSubmissions: %v", err)
+	}
+	if len(pending) != len(submissions) {
+		t.Errorf("%d submissions left in the queue, want %d", len(pending), len(submissions))
+	}
+}
+
+func TestQueuedSubmissionIsFor(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	tests := []struct {
+		name   string
+		queued string
+		server string
+		want   bool
+	}{
+		{name: "same server", queued: "https://missions.eutika.com/api/cli", server: "https://missions.eutika.com/api/cli", want: true},
+		{name: "trailing slash", queued: "https://missions.eutika.com/api/cli/", server: "https://missions.eutika.com/api/cli", want: true},
+		{name: "other host", queued: "https://missions.eutika.com/api/cli", server: "https://evil.example/api/cli", want: false},
+		{name: "other path", queued: "https://missions.eutika.com/api/cli", server: "https://missions.eutika.com/other", want: false},
+		{name: "legacy entry on the default server", queued: "", server: "https://missions.eutika.com/api/cli", want: true},
+		{name: "legacy entry on another server", queued: "", server: "https://evil.example/api/cli", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			submission := &QueuedSubmission{Profile: config.DefaultProfile, Server: tt.queued}
+			if got := submission.IsFor(tt.server); got != tt.want {
+				t.Errorf("IsFor(%q) with %q = %v, want %v", tt.server, tt.queued, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/eutika/eu-missions-cli/internal/version"
+)
+
+// HAR 1.2 archive, as described in http://www.softwareishard.com/blog/har-12-spec/.
+type harArchive struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	// Error is a custom field with the error of requests that got no response.
+	Error string `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harRecorder writes the archive as requests are made. Each entry is written in place of the
+// closing brackets, which are then written again, so the file is a complete archive after every
+// request, even if the command exits early, without rewriting the entries already recorded.
+type harRecorder struct {
+	file    *os.File
+	entries int
+	// size is the length of the file and tail the length of its end that the next entry replaces.
+	size int64
+	tail int
+}
+
+// harEntryIndent is the indentation of the entries in the archive written by json.MarshalIndent.
+const harEntryIndent = "      "
+
+// harClosing closes the entries array, the log and the archive.
+const harClosing = "]\n  }\n}\n"
+
+func newHARRecorder(path string) (*harRecorder, error) {
+	empty, err := json.MarshalIndent(harArchive{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "missions-cli", Version: version.Current()},
+		Entries: []harEntry{},
+	}}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the HAR archive: %w", err)
+	}
+	// Keep the archive up to the opening bracket of the entries.
+	head := empty[:bytes.LastIndex(empty, []byte("[]"))+1]
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600) // #nosec G304 -- the path comes from --har.
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the HAR archive: %w", err)
+	}
+	recorder := &harRecorder{file: file}
+	if err := recorder.write(append(head, harClosing...), len(harClosing)); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to create the HAR archive: %w", err)
+	}
+	return recorder, nil
+}
+
+func (r *harRecorder) add(exchange *tracedExchange) error {
+	entry := harEntry{
+		StartedDateTime: exchange.started.UTC().Format(time.RFC3339Nano),
+		Time:            milliseconds(exchange.wait + exchange.receive),
+		Request: harRequest{
+			Method:      exchange.method,
+			URL:         exchange.url,
+			HTTPVersion: exchange.proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(exchange.requestHead),
+			QueryString: harQuery(exchange.url),
+			HeadersSize: -1,
+			BodySize:    len(exchange.requestBody),
+		},
+		Response: harResponse{
+			Status:      exchange.status,
+			StatusText:  exchange.statusText,
+			HTTPVersion: exchange.proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(exchange.responseHead),
+			Content: harContent{
+				Size:     len(exchange.responseBody),
+				MimeType: exchange.responseType,
+				Text:     exchange.responseBody,
+			},
+			HeadersSize: -1,
+			BodySize:    len(exchange.responseBody),
+		},
+		Timings: harTimings{
+			Send:    0,
+			Wait:    milliseconds(exchange.wait),
+			Receive: milliseconds(exchange.receive),
+		},
+	}
+	if exchange.requestBody != "" {
+		entry.Request.PostData = &harPostData{MimeType: exchange.requestType, Text: exchange.requestBody}
+	}
+	if exchange.err != nil {
+		entry.Error = exchange.err.Error()
+	}
+
+	data, err := json.MarshalIndent(entry, harEntryIndent, "  ")
+	if err != nil {
+		return err
+	}
+	separator := ",\n" + harEntryIndent
+	if r.entries == 0 {
+		separator = "\n" + harEntryIndent
+	}
+	closing := "\n    " + harClosing
+
+	var chunk bytes.Buffer
+	chunk.WriteString(separator)
+	chunk.Write(data)
+	chunk.WriteString(closing)
+
+	if err := r.write(chunk.Bytes(), len(closing)); err != nil {
+		return err
+	}
+	r.entries++
+	return nil
+}
+
+// write writes data in place of the tail of the archive, and remembers that the last tail bytes
+// of data are to be replaced by the next entry.
+func (r *harRecorder) write(data []byte, tail int) error {
+	offset := r.size - int64(r.tail)
+	if _, err := r.file.WriteAt(data, offset); err != nil {
+		return err
+	}
+	r.size = offset + int64(len(data))
+	r.tail = tail
+	return nil
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	pairs := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+func harQuery(rawURL string) []harNameValue {
+	pairs := []harNameValue{}
+	if u, err := url.Parse(rawURL); err == nil {
+		for name, values := range u.Query() {
+			for _, value := range values {
+				pairs = append(pairs, harNameValue{Name: name, Value: value})
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
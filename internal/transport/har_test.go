@@ -0,0 +1,115 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readHARArchive(t *testing.T, path string) harArchive {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var archive harArchive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		t.Fatalf("the HAR archive is not valid JSON: %v\n%s", err, data)
+	}
+	return archive
+}
+
+func TestHARRecorderKeepsArchiveComplete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.har")
+	recorder, err := newHARRecorder(path)
+	if err != nil {
+		t.Fatalf("newHARRecorder: %v", err)
+	}
+
+	archive := readHARArchive(t, path)
+	if archive.Log.Version != "1.2" || archive.Log.Creator.Name != "missions-cli" || len(archive.Log.Entries) != 0 {
+		t.Fatalf("empty archive = %+v, want a HAR 1.2 log without entries", archive.Log)
+	}
+
+	exchanges := []*tracedExchange{
+		{
+			started:      time.Now(),
+			method:       http.MethodGet,
+			url:          "https://missions.eutika.com/api/cli/missions?page=2",
+			proto:        "HTTP/1.1",
+			requestHead:  http.Header{"Authorization": {"Bearer " + redacted}},
+			status:       http.StatusOK,
+			statusText:   "OK",
+			responseHead: http.Header{"Content-Type": {"application/json"}},
+			responseBody: `{"missions":[]}`,
+			responseType: "application/json",
+		},
+		{
+			started:     time.Now(),
+			method:      http.MethodPost,
+			url:         "https://missions.eutika.com/api/cli/commands",
+			proto:       "HTTP/1.1",
+			requestBody: `{"output":"ok"}`,
+			requestType: "application/json",
+			err:         errors.New("connection reset"),
+		},
+		{
+			started:    time.Now(),
+			method:     http.MethodDelete,
+			url:        "https://missions.eutika.com/api/cli/session",
+			proto:      "HTTP/1.1",
+			status:     http.StatusNoContent,
+			statusText: "No Content",
+		},
+	}
+
+	for i, exchange := range exchanges {
+		if err := recorder.add(exchange); err != nil {
+			t.Fatalf("add #%d: %v", i+1, err)
+		}
+
+		// The file is a complete archive after every request.
+		entries := readHARArchive(t, path).Log.Entries
+		if len(entries) != i+1 {
+			t.Fatalf("entries after %d requests = %d", i+1, len(entries))
+		}
+		for j, entry := range entries {
+			if entry.Request.Method != exchanges[j].method || entry.Request.URL != exchanges[j].url {
+				t.Errorf("entry %d = %s %s, want %s %s", j, entry.Request.Method, entry.Request.URL,
+					exchanges[j].method, exchanges[j].url)
+			}
+		}
+	}
+
+	entries := readHARArchive(t, path).Log.Entries
+	if got := entries[0].Response.Content.Text; got != `{"missions":[]}` {
+		t.Errorf("response content = %q", got)
+	}
+	if got := entries[0].Request.QueryString; len(got) != 1 || got[0] != (harNameValue{Name: "page", Value: "2"}) {
+		t.Errorf("query string = %v, want page=2", got)
+	}
+	if entries[1].Request.PostData == nil || entries[1].Request.PostData.Text != `{"output":"ok"}` {
+		t.Errorf("post data = %+v", entries[1].Request.PostData)
+	}
+	if entries[1].Error != "connection reset" {
+		t.Errorf("error = %q, want %q", entries[1].Error, "connection reset")
+	}
+}
+
+func TestHARRecorderTruncatesExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.har")
+	if err := os.WriteFile(path, make([]byte, 4096), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newHARRecorder(path); err != nil {
+		t.Fatalf("newHARRecorder: %v", err)
+	}
+	if entries := readHARArchive(t, path).Log.Entries; len(entries) != 0 {
+		t.Errorf("entries = %d, want none", len(entries))
+	}
+}
@@ -0,0 +1,316 @@
+package transport
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redacted replaces secrets in traces.
+const redacted = "[REDACTED]"
+
+// maxTracedBody limits how much of each body is written to the debug log.
+const maxTracedBody = 16 * 1024
+
+// sensitiveHeaders are the headers whose values never appear in traces.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// sensitiveFields are the JSON, form and query parameters whose values never appear in traces.
+var sensitiveFields = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+	"device_code":   true,
+	"code":          true,
+	"code_verifier": true,
+	"token":         true,
+	"client_secret": true,
+	"password":      true,
+}
+
+// requestIDHeaders are the response headers that may carry the server-side request id.
+var requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id", "X-Amzn-Trace-Id"}
+
+// bearerPattern finds bearer tokens in free text, like error messages.
+var bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`)
+
+// tracer records the requests of the CLI to a debug log, a HAR archive or both.
+type tracer struct {
+	mu      sync.Mutex
+	debug   io.Writer
+	har     *harRecorder
+	counter int
+}
+
+var activeTracer *tracer
+
+// EnableTracing makes every client created by NewClient log its requests and responses to debug,
+// if not nil, and record them in a HAR archive at harPath, if not empty. Secrets are redacted.
+func EnableTracing(debug io.Writer, harPath string) error {
+	if debug == nil && harPath == "" {
+		activeTracer = nil
+		return nil
+	}
+
+	t := &tracer{debug: debug}
+	if harPath != "" {
+		recorder, err := newHARRecorder(harPath)
+		if err != nil {
+			return err
+		}
+		t.har = recorder
+	}
+	activeTracer = t
+	return nil
+}
+
+// tracingTransport passes requests on to the next round tripper and reports them to the tracer.
+type tracingTransport struct {
+	next   http.RoundTripper
+	tracer *tracer
+}
+
+// tracedExchange is a request and its response with the secrets already redacted.
+type tracedExchange struct {
+	started      time.Time
+	method       string
+	url          string
+	proto        string
+	requestHead  http.Header
+	requestBody  string
+	requestType  string
+	status       int
+	statusText   string
+	responseHead http.Header
+	responseBody string
+	responseType string
+	requestID    string
+	wait         time.Duration
+	receive      time.Duration
+	err          error
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	exchange := &tracedExchange{
+		started:     time.Now(),
+		method:      req.Method,
+		url:         redactURL(req.URL),
+		proto:       req.Proto,
+		requestHead: redactHeader(req.Header),
+		requestType: req.Header.Get("Content-Type"),
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			body.Close()
+			exchange.requestBody = redactBody(decodeBody(data, req.Header.Get("Content-Encoding")), exchange.requestType)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	exchange.wait = time.Since(exchange.started)
+	if err != nil {
+		exchange.err = err
+		t.tracer.record(exchange)
+		return nil, err
+	}
+
+	// Read the body now to trace it, and hand an in-memory copy to the caller.
+	received := time.Now()
+	data, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	exchange.receive = time.Since(received)
+
+	exchange.status = resp.StatusCode
+	exchange.statusText = http.StatusText(resp.StatusCode)
+	exchange.responseHead = redactHeader(resp.Header)
+	exchange.responseType = resp.Header.Get("Content-Type")
+	exchange.responseBody = redactBody(data, exchange.responseType)
+	for _, header := range requestIDHeaders {
+		if id := resp.Header.Get(header); id != "" {
+			exchange.requestID = id
+			break
+		}
+	}
+	exchange.err = readErr
+	t.tracer.record(exchange)
+
+	return resp, nil
+}
+
+func (t *tracer) record(exchange *tracedExchange) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.counter++
+	if t.debug != nil {
+		t.log(exchange)
+	}
+	if t.har != nil {
+		if err := t.har.add(exchange); err != nil && t.debug != nil {
+			fmt.Fprintf(t.debug, "[debug] failed to write the HAR archive: %v\n", err)
+		}
+	}
+}
+
+func (t *tracer) log(exchange *tracedExchange) {
+	w := t.debug
+	fmt.Fprintf(w, "[debug] #%d → %s %s\n", t.counter, exchange.method, exchange.url)
+	logHeader(w, exchange.requestHead)
+	logBody(w, exchange.requestBody)
+
+	if exchange.status == 0 {
+		fmt.Fprintf(w, "[debug] #%d ✗ %v (%s)\n", t.counter, exchange.err, exchange.wait.Round(time.Millisecond))
+		return
+	}
+
+	fmt.Fprintf(w, "[debug] #%d ← %d %s (%s)", t.counter, exchange.status, exchange.statusText,
+		(exchange.wait + exchange.receive).Round(time.Millisecond))
+	if exchange.requestID != "" {
+		fmt.Fprintf(w, " request-id=%s", exchange.requestID)
+	}
+	fmt.Fprintln(w)
+	logHeader(w, exchange.responseHead)
+	logBody(w, exchange.responseBody)
+	if exchange.err != nil {
+		fmt.Fprintf(w, "[debug]   error reading body: %v\n", exchange.err)
+	}
+}
+
+func logHeader(w io.Writer, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "[debug]   %s: %s\n", name, strings.Join(header[name], ", "))
+	}
+}
+
+func logBody(w io.Writer, body string) {
+	if body == "" {
+		return
+	}
+	if len(body) > maxTracedBody {
+		body = body[:maxTracedBody] + fmt.Sprintf("… (%d bytes more)", len(body)-maxTracedBody)
+	}
+	fmt.Fprintf(w, "[debug]   %s\n", strings.ReplaceAll(body, "\n", "\n[debug]   "))
+}
+
+// decodeBody undoes the gzip compression of a request body, so traces show the actual content.
+func decodeBody(data []byte, contentEncoding string) []byte {
+	if !strings.EqualFold(contentEncoding, "gzip") {
+		return data
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return data
+	}
+	defer reader.Close()
+
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return data
+	}
+	return decoded
+}
+
+func redactHeader(header http.Header) http.Header {
+	clean := header.Clone()
+	for name := range clean {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			for i, value := range clean[name] {
+				// Keep the scheme, which tells apart a missing token from a wrong one.
+				if scheme, _, found := strings.Cut(value, " "); found && name != "Cookie" && name != "Set-Cookie" {
+					clean[name][i] = scheme + " " + redacted
+				} else {
+					clean[name][i] = redacted
+				}
+			}
+		}
+	}
+	return clean
+}
+
+func redactURL(u *url.URL) string {
+	clean := *u
+	clean.User = nil
+	if query := clean.Query(); len(query) > 0 {
+		clean.RawQuery = redactValues(query)
+	}
+	return clean.String()
+}
+
+// redactValues encodes form or query values with the secrets hidden.
+func redactValues(values url.Values) string {
+	for key := range values {
+		if sensitiveFields[strings.ToLower(key)] {
+			for i := range values[key] {
+				values[key][i] = redacted
+			}
+		}
+	}
+	// Keep the marker readable instead of percent-encoded.
+	return strings.ReplaceAll(values.Encode(), url.QueryEscape(redacted), redacted)
+}
+
+// redactBody hides the secrets of a JSON or form body. Other bodies only have bearer tokens hidden.
+func redactBody(data []byte, contentType string) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(string(data)); err == nil {
+			return redactValues(values)
+		}
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") || json.Valid(data):
+		var document any
+		if err := json.Unmarshal(data, &document); err == nil {
+			if clean, marshalErr := json.Marshal(redactJSON(document)); marshalErr == nil {
+				return string(clean)
+			}
+		}
+	}
+
+	return bearerPattern.ReplaceAllString(string(data), "${1}"+redacted)
+}
+
+func redactJSON(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if sensitiveFields[strings.ToLower(key)] {
+				v[key] = redacted
+			} else {
+				v[key] = redactJSON(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactJSON(item)
+		}
+	case string:
+		return bearerPattern.ReplaceAllString(v, "${1}"+redacted)
+	}
+	return value
+}
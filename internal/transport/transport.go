@@ -0,0 +1,143 @@
+// Package transport builds the HTTP client shared by every request of the CLI to Missions and
+// its authorization server.
+package transport
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+)
+
+// officialHost is the only host certificate pinning applies to.
+const officialHost = "missions.eutika.com"
+
+// proxyNone disables the proxy, whatever the environment says.
+const proxyNone = "none"
+
+// NewClient returns an HTTP client configured from cfg: proxy, extra certificate authorities,
+// client certificate, key pinning for the official host, and connect, read and overall timeouts.
+// Requests are traced when EnableTracing was called.
+func NewClient(cfg *config.Config) (*http.Client, error) {
+	transport, err := NewTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var roundTripper http.RoundTripper = transport
+	if activeTracer != nil {
+		roundTripper = &tracingTransport{next: transport, tracer: activeTracer}
+	}
+	return &http.Client{Transport: roundTripper, Timeout: cfg.GetRequestTimeout()}, nil
+}
+
+// NewTransport returns the round tripper used by NewClient.
+func NewTransport(cfg *config.Config) (*http.Transport, error) {
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   cfg.GetConnectTimeout(),
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.TLSHandshakeTimeout = cfg.GetConnectTimeout()
+	transport.ResponseHeaderTimeout = cfg.GetReadTimeout()
+	transport.TLSClientConfig = tlsConfig
+
+	// Use the configured proxy, none at all, or the one given by the standard environment variables.
+	switch setting := cfg.GetProxy(); setting {
+	case "":
+		transport.Proxy = http.ProxyFromEnvironment
+	case proxyNone:
+		transport.Proxy = nil
+	default:
+		proxyURL, parseErr := url.Parse(setting)
+		if parseErr != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q: expected a URL like http://proxy.example.com:3128", setting)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return transport, nil
+}
+
+func newTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if bundle := cfg.GetCABundle(); bundle != "" {
+		pool, err := loadCABundle(bundle)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if certFile, keyFile := cfg.GetClientCertificate(); certFile != "" {
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate %s: %w", certFile, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	if pins := cfg.GetPinnedKeys(); len(pins) > 0 {
+		tlsConfig.VerifyConnection = verifyPinnedKeys(pins)
+	}
+
+	return tlsConfig, nil
+}
+
+// loadCABundle returns the system certificate pool with the certificates of the bundle added.
+func loadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates found in CA bundle %s", path)
+	}
+	return pool, nil
+}
+
+// verifyPinnedKeys accepts a connection to the official host only if a certificate of the
+// verified chain has one of the pinned public keys. Other hosts are not affected.
+func verifyPinnedKeys(pins []string) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		if !strings.EqualFold(state.ServerName, officialHost) {
+			return nil
+		}
+
+		for _, chain := range state.VerifiedChains {
+			for _, certificate := range chain {
+				sum := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+				hash := base64.StdEncoding.EncodeToString(sum[:])
+				for _, pin := range pins {
+					if hash == pin {
+						return nil
+					}
+				}
+			}
+		}
+		return errors.New("the certificate of " + officialHost + " does not match any pinned key")
+	}
+}
@@ -0,0 +1,285 @@
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eutika/eu-missions-cli/internal/config"
+)
+
+// newTestCertificate returns a self-signed certificate and the PEM file holding it and its key.
+func newTestCertificate(t *testing.T) (*x509.Certificate, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "student"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})...)
+	path := filepath.Join(t.TempDir(), "client.pem")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certificate, path
+}
+
+// writeServerCA writes the certificate of a TLS test server to a PEM file.
+func writeServerCA(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newTestClient(t *testing.T) *http.Client {
+	t.Helper()
+	client, err := NewClient(config.NewConfig())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+func TestNewClientTimeouts(t *testing.T) {
+	t.Setenv("MISSIONS_CLI_TIMEOUT", "45s")
+	t.Setenv("MISSIONS_CLI_CONNECT_TIMEOUT", "3s")
+	t.Setenv("MISSIONS_CLI_READ_TIMEOUT", "7s")
+
+	client := newTestClient(t)
+	if client.Timeout != 45*time.Second {
+		t.Errorf("Timeout = %s, want 45s", client.Timeout)
+	}
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Transport = %T, want *http.Transport", client.Transport)
+	}
+	if transport.TLSHandshakeTimeout != 3*time.Second {
+		t.Errorf("TLSHandshakeTimeout = %s, want 3s", transport.TLSHandshakeTimeout)
+	}
+	if transport.ResponseHeaderTimeout != 7*time.Second {
+		t.Errorf("ResponseHeaderTimeout = %s, want 7s", transport.ResponseHeaderTimeout)
+	}
+	if transport.TLSClientConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("MinVersion = %x, want TLS 1.2", transport.TLSClientConfig.MinVersion)
+	}
+}
+
+func TestNewTransportProxy(t *testing.T) {
+	request, err := http.NewRequest(http.MethodGet, "https://missions.eutika.com/api/cli/missions", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		setting   string
+		wantProxy string
+		wantNone  bool
+		wantErr   bool
+	}{
+		{name: "configured proxy", setting: "http://proxy.example.com:3128", wantProxy: "http://proxy.example.com:3128"},
+		{name: "surrounding spaces", setting: " http://proxy.example.com:3128 ", wantProxy: "http://proxy.example.com:3128"},
+		{name: "disabled", setting: "none", wantNone: true},
+		{name: "without scheme", setting: "proxy.example.com:3128", wantErr: true},
+		{name: "not a URL", setting: "http://[::1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MISSIONS_CLI_PROXY", tt.setting)
+			transport, err := NewTransport(config.NewConfig())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("NewTransport accepted an invalid proxy URL")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewTransport: %v", err)
+			}
+
+			if tt.wantNone {
+				if transport.Proxy != nil {
+					t.Error("the proxy was not disabled")
+				}
+				return
+			}
+			proxyURL, err := transport.Proxy(request)
+			if err != nil || proxyURL == nil || proxyURL.String() != tt.wantProxy {
+				t.Errorf("Proxy = %v, %v, want %s", proxyURL, err, tt.wantProxy)
+			}
+		})
+	}
+
+	t.Run("from the environment", func(t *testing.T) {
+		t.Setenv("MISSIONS_CLI_PROXY", "")
+		transport, err := NewTransport(config.NewConfig())
+		if err != nil {
+			t.Fatalf("NewTransport: %v", err)
+		}
+		if transport.Proxy == nil {
+			t.Error("the proxy environment variables are ignored")
+		}
+	})
+}
+
+func TestCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	t.Cleanup(server.Close)
+
+	t.Run("untrusted server", func(t *testing.T) {
+		t.Setenv("MISSIONS_CLI_CA_BUNDLE", "")
+		_, err := newTestClient(t).Get(server.URL)
+		var unknownAuthority x509.UnknownAuthorityError
+		if !errors.As(err, &unknownAuthority) {
+			t.Errorf("Get = %v, want an unknown authority error", err)
+		}
+	})
+
+	t.Run("trusted through the bundle", func(t *testing.T) {
+		t.Setenv("MISSIONS_CLI_CA_BUNDLE", writeServerCA(t, server))
+		resp, err := newTestClient(t).Get(server.URL)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		resp.Body.Close()
+	})
+
+	t.Run("missing bundle", func(t *testing.T) {
+		t.Setenv("MISSIONS_CLI_CA_BUNDLE", filepath.Join(t.TempDir(), "missing.pem"))
+		if _, err := NewClient(config.NewConfig()); err == nil {
+			t.Error("NewClient accepted a CA bundle that does not exist")
+		}
+	})
+
+	t.Run("bundle without certificates", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "empty.pem")
+		if err := os.WriteFile(path, []byte("not a certificate"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("MISSIONS_CLI_CA_BUNDLE", path)
+		if _, err := NewClient(config.NewConfig()); err == nil {
+			t.Error("NewClient accepted a CA bundle without certificates")
+		}
+	})
+}
+
+func TestClientCertificate(t *testing.T) {
+	certificate, path := newTestCertificate(t)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || !r.TLS.PeerCertificates[0].Equal(certificate) {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	t.Setenv("MISSIONS_CLI_CA_BUNDLE", writeServerCA(t, server))
+	t.Setenv("MISSIONS_CLI_CLIENT_CERT", path)
+	t.Setenv("MISSIONS_CLI_CLIENT_KEY", "")
+
+	resp, err := newTestClient(t).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want the client certificate accepted", resp.StatusCode)
+	}
+
+	t.Setenv("MISSIONS_CLI_CLIENT_CERT", filepath.Join(t.TempDir(), "missing.pem"))
+	if _, err := NewClient(config.NewConfig()); err == nil {
+		t.Error("NewClient accepted a client certificate that does not exist")
+	}
+}
+
+func TestVerifyPinnedKeys(t *testing.T) {
+	certificate, _ := newTestCertificate(t)
+	sum := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(sum[:])
+	otherPin := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	tests := []struct {
+		name       string
+		serverName string
+		pins       []string
+		wantErr    bool
+	}{
+		{name: "pinned key", serverName: officialHost, pins: []string{otherPin, pin}},
+		{name: "host in upper case", serverName: "MISSIONS.eutika.com", pins: []string{pin}},
+		{name: "key not pinned", serverName: officialHost, pins: []string{otherPin}, wantErr: true},
+		{name: "another host", serverName: "auth.eutika.com", pins: []string{otherPin}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := tls.ConnectionState{
+				ServerName:     tt.serverName,
+				VerifiedChains: [][]*x509.Certificate{{certificate}},
+			}
+			if err := verifyPinnedKeys(tt.pins)(state); (err != nil) != tt.wantErr {
+				t.Errorf("verifyPinnedKeys = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewTransportPinsOnlyWhenConfigured(t *testing.T) {
+	t.Setenv("MISSIONS_CLI_PINNED_KEYS", "")
+	transport, err := NewTransport(config.NewConfig())
+	if err != nil {
+		t.Fatalf("NewTransport: %v", err)
+	}
+	if transport.TLSClientConfig.VerifyConnection != nil {
+		t.Error("keys are pinned without MISSIONS_CLI_PINNED_KEYS")
+	}
+
+	t.Setenv("MISSIONS_CLI_PINNED_KEYS", "sha256/AAAA, BBBB")
+	transport, err = NewTransport(config.NewConfig())
+	if err != nil {
+		t.Fatalf("NewTransport: %v", err)
+	}
+	if transport.TLSClientConfig.VerifyConnection == nil {
+		t.Error("MISSIONS_CLI_PINNED_KEYS is ignored")
+	}
+}
@@ -0,0 +1,149 @@
+// Package version identifies the running build of the CLI.
+package version
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// Version is set at build time with
+//
+//	-ldflags "-X github.com/eutika/eu-missions-cli/internal/version.Version=1.2.3"
+//
+// Builds without it report the module version recorded by 'go install', or "dev".
+var Version = ""
+
+// devVersion identifies local builds, which are never considered outdated.
+const devVersion = "dev"
+
+// Current returns the version of the running build, without a leading "v".
+func Current() string {
+	if Version != "" {
+		return strings.TrimPrefix(Version, "v")
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && isRelease(info.Main.Version) {
+		return strings.TrimPrefix(info.Main.Version, "v")
+	}
+	return devVersion
+}
+
+// pseudoVersionPattern matches the timestamp and commit of Go pseudo-versions, which 'go build'
+// records for untagged checkouts, like v0.0.0-20240101120000-0123456789ab.
+var pseudoVersionPattern = regexp.MustCompile(`\d{14}-[0-9a-f]{12}`)
+
+// isRelease reports whether a module version recorded by the Go toolchain names a tagged release.
+func isRelease(v string) bool {
+	return v != "" && v != "(devel)" && !strings.Contains(v, "+dirty") && !pseudoVersionPattern.MatchString(v)
+}
+
+// IsDev reports whether the running build has no release version.
+func IsDev() bool {
+	_, ok := parse(Current())
+	return !ok
+}
+
+// UserAgent returns the User-Agent sent to Missions, e.g. "missions-cli/1.2.3 (linux; amd64)".
+func UserAgent() string {
+	return fmt.Sprintf("missions-cli/%s (%s; %s)", Current(), runtime.GOOS, runtime.GOARCH)
+}
+
+// Compare compares two versions of the form MAJOR.MINOR.PATCH, with an optional "v" prefix and
+// pre-release suffix, returning -1, 0 or +1. A pre-release sorts before its release. Versions
+// that can't be parsed compare as equal, so they never block anything.
+func Compare(a, b string) int {
+	va, okA := parse(a)
+	vb, okB := parse(b)
+	if !okA || !okB {
+		return 0
+	}
+
+	for i := range va.numbers {
+		if va.numbers[i] != vb.numbers[i] {
+			if va.numbers[i] < vb.numbers[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case va.prerelease == vb.prerelease:
+		return 0
+	case va.prerelease == "":
+		return 1
+	case vb.prerelease == "":
+		return -1
+	default:
+		return comparePrerelease(va.prerelease, vb.prerelease)
+	}
+}
+
+// comparePrerelease compares two pre-release suffixes as SemVer 2.0.0, section 11, does: identifier
+// by identifier, numeric ones as numbers and below alphanumeric ones, so rc.9 sorts before rc.10.
+// When one runs out of identifiers first, it sorts first.
+func comparePrerelease(a, b string) int {
+	idsA := strings.Split(a, ".")
+	idsB := strings.Split(b, ".")
+
+	for i := range min(len(idsA), len(idsB)) {
+		numA, isNumA := numericIdentifier(idsA[i])
+		numB, isNumB := numericIdentifier(idsB[i])
+
+		var c int
+		switch {
+		case isNumA && isNumB:
+			c = cmp.Compare(numA, numB)
+		case isNumA:
+			c = -1
+		case isNumB:
+			c = 1
+		default:
+			c = strings.Compare(idsA[i], idsB[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(idsA), len(idsB))
+}
+
+// numericIdentifier returns the value of a pre-release identifier made only of digits.
+func numericIdentifier(id string) (uint64, bool) {
+	if id == "" || strings.TrimLeft(id, "0123456789") != "" {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(id, 10, 64)
+	return n, err == nil
+}
+
+type semver struct {
+	numbers    [3]int
+	prerelease string
+}
+
+func parse(v string) (semver, bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	// Build metadata doesn't take part in comparisons.
+	v, _, _ = strings.Cut(v, "+")
+	v, prerelease, _ := strings.Cut(v, "-")
+
+	parts := strings.Split(v, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return semver{}, false
+	}
+
+	parsed := semver{prerelease: prerelease}
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return semver{}, false
+		}
+		parsed.numbers[i] = number
+	}
+	return parsed, true
+}
@@ -0,0 +1,78 @@
+package version
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.10.0", "1.9.0", 1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.2.3+build.5", "1.2.3", 0},
+
+		// A pre-release sorts before its release.
+		{"1.2.0-rc.1", "1.2.0", -1},
+		{"1.2.0", "1.2.0-rc.1", 1},
+
+		// Pre-release identifiers, as in SemVer 2.0.0, section 11.
+		{"1.2.0-rc.9", "1.2.0-rc.10", -1},
+		{"1.2.0-rc.10", "1.2.0-rc.9", 1},
+		{"1.2.0-alpha", "1.2.0-alpha.1", -1},
+		{"1.2.0-alpha.1", "1.2.0-alpha.beta", -1},
+		{"1.2.0-alpha.beta", "1.2.0-beta", -1},
+		{"1.2.0-beta.2", "1.2.0-beta.11", -1},
+		{"1.2.0-beta.11", "1.2.0-rc.1", -1},
+		{"1.2.0-rc.1", "1.2.0-rc.1", 0},
+		{"1.2.0-rc.01x", "1.2.0-rc.1", 1},
+
+		// Versions that can't be parsed never block anything.
+		{"dev", "1.2.3", 0},
+		{"1.2.3", "latest", 0},
+		{"1.2.3.4", "1.2.3", 0},
+	}
+
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestIsRelease(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"v1.2.3", true},
+		{"v1.2.3-rc.1", true},
+		{"", false},
+		{"(devel)", false},
+		{"v1.2.3+dirty", false},
+		{"v0.0.0-20240101120000-0123456789ab", false},
+		{"v1.2.4-0.20240101120000-0123456789ab", false},
+	}
+
+	for _, tt := range tests {
+		if got := isRelease(tt.version); got != tt.want {
+			t.Errorf("isRelease(%q) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestCurrent(t *testing.T) {
+	original := Version
+	t.Cleanup(func() { Version = original })
+
+	Version = "v1.4.0"
+	if got := Current(); got != "1.4.0" {
+		t.Errorf("Current() = %q, want %q", got, "1.4.0")
+	}
+	if IsDev() {
+		t.Error("IsDev() = true for a release build")
+	}
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/eutika/eu-missions-cli/pkg/types"
+)
+
+// maxListPages bounds how many pages are requested for a single listing.
+const maxListPages = 100
+
+// StageResponse is the definition of a stage together with the validators needed to revalidate it.
+type StageResponse struct {
+	types.Command
+	ETag         string
+	LastModified string
+	// NotModified is set when a conditional request found the stage unchanged. Command is empty then.
+	NotModified bool
+}
+
+// GetStage fetches the definition of a stage: its title, description and the commands to run.
+// Use IfNoneMatch or IfModifiedSince to revalidate a copy the caller already has.
+func (c *Client) GetStage(ctx context.Context, stageID string, opts ...RequestOption) (*StageResponse, error) {
+	endpoint := fmt.Sprintf("%s/commands/%s", c.baseURL, url.PathEscape(stageID))
+	resp, err := c.do(ctx, http.MethodGet, endpoint, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	stage := &StageResponse{
+		ETag:         resp.header.Get("ETag"),
+		LastModified: resp.header.Get("Last-Modified"),
+		NotModified:  resp.statusCode == http.StatusNotModified,
+	}
+	if !stage.NotModified {
+		if err := decode(resp.body, &stage.Command); err != nil {
+			return nil, err
+		}
+	}
+	return stage, nil
+}
+
+// Validate sends the output of the commands of a stage for a practice check. The attempt is not recorded.
+func (c *Client) Validate(ctx context.Context, stageID string, results []string, opts ...RequestOption) (*types.ValidationResponse, error) {
+	return c.sendResults(ctx, "validate", stageID, results, opts)
+}
+
+// Submit sends the output of the commands of a stage as an attempt that counts towards the mission.
+// Pass WithIdempotencyKey so a resent submission is not counted twice.
+func (c *Client) Submit(ctx context.Context, stageID string, results []string, opts ...RequestOption) (*types.ValidationResponse, error) {
+	return c.sendResults(ctx, "submit", stageID, results, opts)
+}
+
+func (c *Client) sendResults(ctx context.Context, endpoint, stageID string, results []string, opts []RequestOption) (*types.ValidationResponse, error) {
+	payload := types.CommandResult{ID: stageID, Results: results}
+	resp, err := c.do(ctx, http.MethodPost, fmt.Sprintf("%s/%s", c.baseURL, endpoint), payload, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return types.DecodeValidationResponse(resp.body)
+}
+
+// ListMissions returns every mission of the student, going through all the pages.
+func (c *Client) ListMissions(ctx context.Context) ([]types.Mission, error) {
+	var missions []types.Mission
+	err := c.fetchPages(ctx, c.baseURL+"/missions", func(body []byte) (int, error) {
+		var page types.MissionPage
+		if err := decode(body, &page); err != nil {
+			return 0, err
+		}
+		missions = append(missions, page.Items...)
+		return page.TotalPages, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return missions, nil
+}
+
+// ListStages returns every stage of a mission together with the progress of the student.
+func (c *Client) ListStages(ctx context.Context, missionID string) ([]types.StageSummary, error) {
+	var stages []types.StageSummary
+	endpoint := fmt.Sprintf("%s/missions/%s/stages", c.baseURL, url.PathEscape(missionID))
+	err := c.fetchPages(ctx, endpoint, func(body []byte) (int, error) {
+		var page types.StageSummaryPage
+		if err := decode(body, &page); err != nil {
+			return 0, err
+		}
+		stages = append(stages, page.Items...)
+		return page.TotalPages, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stages, nil
+}
+
+// fetchPages requests the pages of a listing one after another. decodePage consumes the body of
+// a page and returns the total number of pages reported by the server.
+func (c *Client) fetchPages(ctx context.Context, endpoint string, decodePage func([]byte) (int, error)) error {
+	for page := 1; page <= maxListPages; page++ {
+		pageURL, err := url.Parse(endpoint)
+		if err != nil {
+			return fmt.Errorf("invalid URL %s: %w", endpoint, err)
+		}
+		query := pageURL.Query()
+		query.Set("page", strconv.Itoa(page))
+		pageURL.RawQuery = query.Encode()
+
+		resp, err := c.do(ctx, http.MethodGet, pageURL.String(), nil)
+		if err != nil {
+			return err
+		}
+
+		totalPages, err := decodePage(resp.body)
+		if err != nil {
+			return err
+		}
+		if page >= totalPages {
+			return nil
+		}
+	}
+	return fmt.Errorf("listing has more than %d pages", maxListPages)
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+// pagedHandler serves a listing with totalPages pages of one item each, and records the pages requested.
+func pagedHandler(t *testing.T, path string, totalPages int, requested *[]int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != path {
+			t.Errorf("path = %q, want %q", r.URL.EscapedPath(), path)
+		}
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil {
+			t.Errorf("page = %q, want a number", r.URL.Query().Get("page"))
+		}
+		*requested = append(*requested, page)
+		fmt.Fprintf(w, `{"items":[{"id":"item-%d"}],"page":%d,"totalPages":%d}`, page, page, totalPages)
+	}
+}
+
+func TestListMissionsPagination(t *testing.T) {
+	tests := []struct {
+		name       string
+		totalPages int
+		wantPages  []int
+	}{
+		{name: "single page", totalPages: 1, wantPages: []int{1}},
+		{name: "empty listing", totalPages: 0, wantPages: []int{1}},
+		{name: "several pages", totalPages: 3, wantPages: []int{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requested []int
+			api := newTestClient(t, pagedHandler(t, "/missions", tt.totalPages, &requested))
+
+			missions, err := api.ListMissions(context.Background())
+			if err != nil {
+				t.Fatalf("ListMissions: %v", err)
+			}
+			if fmt.Sprint(requested) != fmt.Sprint(tt.wantPages) {
+				t.Errorf("pages requested = %v, want %v", requested, tt.wantPages)
+			}
+			if len(missions) != len(tt.wantPages) {
+				t.Fatalf("missions = %d, want %d", len(missions), len(tt.wantPages))
+			}
+			for i, mission := range missions {
+				if want := fmt.Sprintf("item-%d", i+1); mission.ID != want {
+					t.Errorf("mission %d = %q, want %q", i, mission.ID, want)
+				}
+			}
+		})
+	}
+}
+
+func TestListStagesPagination(t *testing.T) {
+	var requested []int
+	api := newTestClient(t, pagedHandler(t, "/missions/mission%2F1/stages", 2, &requested))
+
+	stages, err := api.ListStages(context.Background(), "mission/1")
+	if err != nil {
+		t.Fatalf("ListStages: %v", err)
+	}
+	if len(stages) != 2 || stages[0].ID != "item-1" || stages[1].ID != "item-2" {
+		t.Errorf("stages = %+v, want one per page", stages)
+	}
+}
+
+func TestListMissionsTooManyPages(t *testing.T) {
+	var requested []int
+	api := newTestClient(t, pagedHandler(t, "/missions", maxListPages+1, &requested))
+
+	if _, err := api.ListMissions(context.Background()); err == nil {
+		t.Fatal("ListMissions succeeded for a listing with too many pages")
+	}
+	if len(requested) != maxListPages {
+		t.Errorf("pages requested = %d, want %d", len(requested), maxListPages)
+	}
+}
+
+func TestListMissionsStopsOnError(t *testing.T) {
+	requests := 0
+	api := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("page") == "2" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"items":[{"id":"m1"}],"page":1,"totalPages":3}`))
+	})
+
+	if missions, err := api.ListMissions(context.Background()); err == nil {
+		t.Fatalf("ListMissions = %+v, want the error of the second page", missions)
+	}
+	if requests != 2 {
+		t.Errorf("requests = %d, want 2", requests)
+	}
+}
+
+func TestListMissionsInvalidPage(t *testing.T) {
+	api := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`<html>maintenance</html>`))
+	})
+
+	if _, err := api.ListMissions(context.Background()); err == nil {
+		t.Error("ListMissions succeeded with a page that is not JSON")
+	}
+}
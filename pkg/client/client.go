@@ -0,0 +1,360 @@
+// Package client is a Go client for the Missions API, for tools that need to fetch stages,
+// send results or list missions on behalf of a student.
+//
+// The client reports failures with typed errors and leaves refreshing tokens and storing
+// credentials to the caller. Failed requests are only retried with the policy given in WithRetry:
+//
+//	api := client.New(client.WithTokenSource(client.StaticToken(os.Getenv("MISSIONS_TOKEN"))))
+//	stage, err := api.GetStage(ctx, "stage-id")
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBaseURL is the Missions API used when no base URL is given.
+const DefaultBaseURL = "https://missions.eutika.com/api/cli"
+
+// defaultUserAgent identifies the client when no user agent is given.
+const defaultUserAgent = "missions-go-client"
+
+// maxErrorBodyLength limits how much of an error response is kept in a *StatusError.
+const maxErrorBodyLength = 512
+
+// defaultCompressionThreshold is the body size from which requests are compressed with gzip.
+const defaultCompressionThreshold = 16 * 1024
+
+// TokenSource supplies the access token sent with each request. It receives the URL of the
+// request, so implementations can refuse to hand a token to an unexpected server.
+type TokenSource interface {
+	Token(ctx context.Context, requestURL string) (string, error)
+}
+
+// StaticToken is a TokenSource that always returns the same access token.
+type StaticToken string
+
+func (t StaticToken) Token(context.Context, string) (string, error) {
+	if t == "" {
+		return "", errors.New("empty access token")
+	}
+	return string(t), nil
+}
+
+// TokenSourceFunc adapts a function to the TokenSource interface.
+type TokenSourceFunc func(ctx context.Context, requestURL string) (string, error)
+
+func (f TokenSourceFunc) Token(ctx context.Context, requestURL string) (string, error) {
+	return f(ctx, requestURL)
+}
+
+// ResponseHook inspects every response before the client handles it. Returning an error
+// makes the call fail with that error.
+type ResponseHook func(*http.Response) error
+
+// RetryFunc runs attempt, which sends a single HTTP request, again until it succeeds or the
+// function gives up, and returns the last error. Listings call it once for every page, so a
+// failed page doesn't start the listing over.
+type RetryFunc func(ctx context.Context, attempt func() error) error
+
+// Client calls the Missions API. It is safe for concurrent use once created.
+type Client struct {
+	baseURL     string
+	httpClient  *http.Client
+	tokenSource TokenSource
+	userAgent   string
+	header      http.Header
+	hooks       []ResponseHook
+	retry       RetryFunc
+	// compressionThreshold is the body size from which requests are compressed, or 0 to never compress.
+	compressionThreshold int
+	// acceptsGzip is set once the server advertises gzip request bodies with Accept-Encoding (RFC 7694).
+	acceptsGzip atomic.Bool
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL sets the URL of the Missions API, DefaultBaseURL by default.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for requests, a client with a 30 second timeout by default.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTokenSource sets where access tokens come from. Without one, requests are not authenticated.
+func WithTokenSource(tokenSource TokenSource) Option {
+	return func(c *Client) {
+		c.tokenSource = tokenSource
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithHeader adds a header to every request.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+// WithResponseHook adds a hook that inspects every response, for example to check version headers.
+func WithResponseHook(hook ResponseHook) Option {
+	return func(c *Client) {
+		c.hooks = append(c.hooks, hook)
+	}
+}
+
+// WithRetry sets how failed requests are retried. By default every request is sent only once.
+func WithRetry(retry RetryFunc) Option {
+	return func(c *Client) {
+		c.retry = retry
+	}
+}
+
+// WithCompressionThreshold sets the body size from which requests are compressed with gzip, once
+// the server has advertised support for it in an Accept-Encoding response header. Zero or less
+// disables compression. The default is 16 KiB.
+func WithCompressionThreshold(size int) Option {
+	return func(c *Client) {
+		c.compressionThreshold = max(size, 0)
+	}
+}
+
+// New creates a client for the Missions API.
+func New(opts ...Option) *Client {
+	const defaultTimeout = 30 * time.Second
+
+	c := &Client{
+		baseURL:    DefaultBaseURL,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		userAgent:  defaultUserAgent,
+		header:     make(http.Header),
+
+		compressionThreshold: defaultCompressionThreshold,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// BaseURL returns the URL of the Missions API the client talks to.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// RequestOption configures a single request.
+type RequestOption func(*http.Request)
+
+// WithIdempotencyKey sends an Idempotency-Key header, so the server processes a submission only
+// once however many times it is sent.
+func WithIdempotencyKey(key string) RequestOption {
+	return func(req *http.Request) {
+		req.Header.Set("Idempotency-Key", key)
+	}
+}
+
+// IfNoneMatch makes the request conditional on the resource no longer having the given ETag.
+func IfNoneMatch(etag string) RequestOption {
+	return func(req *http.Request) {
+		req.Header.Set("If-None-Match", etag)
+	}
+}
+
+// IfModifiedSince makes the request conditional on the resource having changed since the given
+// Last-Modified value.
+func IfModifiedSince(lastModified string) RequestOption {
+	return func(req *http.Request) {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+}
+
+// response is a successful response: 200, or 304 to a conditional request.
+type response struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+// do sends a request to the API and returns the response, or a typed error for any status
+// other than 200 and, for conditional requests, 304.
+func (c *Client) do(ctx context.Context, method, requestURL string, payload any, opts ...RequestOption) (*response, error) {
+	var data []byte
+	if payload != nil {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return nil, fmt.Errorf("failed to marshal request payload: %w", err)
+		}
+	}
+
+	var resp *response
+	attempt := func() (err error) {
+		compress := c.compressionThreshold > 0 && len(data) >= c.compressionThreshold && c.acceptsGzip.Load()
+		resp, err = c.send(ctx, method, requestURL, data, compress, opts)
+
+		// A server that stopped accepting gzip answers 415; send the body as it is instead.
+		var statusErr *StatusError
+		if compress && errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusUnsupportedMediaType {
+			c.acceptsGzip.Store(false)
+			resp, err = c.send(ctx, method, requestURL, data, false, opts)
+		}
+		return err
+	}
+
+	var err error
+	if c.retry == nil {
+		err = attempt()
+	} else {
+		err = c.retry(ctx, attempt)
+	}
+	return resp, err
+}
+
+// send makes a single request, with data as the JSON body if not nil.
+func (c *Client) send(ctx context.Context, method, requestURL string, data []byte, compress bool, opts []RequestOption) (*response, error) {
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+		if compress {
+			compressed, err := gzipBody(data)
+			if err != nil {
+				return nil, err
+			}
+			body = bytes.NewReader(compressed)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.tokenSource != nil {
+		token, tokenErr := c.tokenSource.Token(ctx, requestURL)
+		if tokenErr != nil {
+			return nil, tokenErr
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", "application/json")
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if advertisesGzip(resp.Header) {
+		c.acceptsGzip.Store(true)
+	}
+
+	for _, hook := range c.hooks {
+		if hookErr := hook(resp); hookErr != nil {
+			return nil, hookErr
+		}
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, &UnauthorizedError{Challenge: parseBearerChallenge(resp.Header.Get("WWW-Authenticate"))}
+	}
+
+	conditional := req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != ""
+	if resp.StatusCode == http.StatusNotModified && conditional {
+		return &response{statusCode: resp.StatusCode, header: resp.Header}, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		errorBody, readErr := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+		if readErr != nil {
+			return nil,
+				fmt.Errorf("request failed with status %d, could not read response body: %w", resp.StatusCode, readErr)
+		}
+
+		return nil, &StatusError{
+			StatusCode: resp.StatusCode,
+			Body:       strings.TrimSpace(string(errorBody)),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return &response{statusCode: resp.StatusCode, header: resp.Header, body: responseBody}, nil
+}
+
+// advertisesGzip reports whether a response says the server accepts gzip request bodies.
+func advertisesGzip(header http.Header) bool {
+	for _, value := range header.Values("Accept-Encoding") {
+		for _, coding := range strings.Split(value, ",") {
+			name, params, _ := strings.Cut(strings.TrimSpace(coding), ";")
+			if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+				continue
+			}
+			// A quality of zero means "not acceptable" (RFC 9110, section 12.4.2).
+			_, quality, found := strings.Cut(strings.ReplaceAll(params, " ", ""), "q=")
+			if weight, err := strconv.ParseFloat(quality, 64); !found || err != nil || weight > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func gzipBody(data []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to compress request body: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress request body: %w", err)
+	}
+	return compressed.Bytes(), nil
+}
+
+// decode unmarshals the body of a response.
+func decode(data []byte, v any) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,420 @@
+package client
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestClient starts a server with the given handler and returns a client that talks to it.
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return New(append([]Option{WithBaseURL(server.URL)}, opts...)...)
+}
+
+// retryTimes retries an attempt up to the given number of times and counts how often it was called.
+type retryTimes struct {
+	mu    sync.Mutex
+	times int
+	calls int
+}
+
+func (r *retryTimes) retry(_ context.Context, attempt func() error) error {
+	r.mu.Lock()
+	r.calls++
+	r.mu.Unlock()
+
+	var err error
+	for i := 0; i < r.times; i++ {
+		if err = attempt(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func TestClientSendsTokenAndHeaders(t *testing.T) {
+	var requestURL string
+	tokens := TokenSourceFunc(func(_ context.Context, u string) (string, error) {
+		requestURL = u
+		return "access", nil
+	})
+
+	api := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer access" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer access")
+		}
+		if got := r.Header.Get("User-Agent"); got != "missions-test" {
+			t.Errorf("User-Agent = %q, want %q", got, "missions-test")
+		}
+		if got := r.Header.Get("X-Lab"); got != "lab-1" {
+			t.Errorf("X-Lab = %q, want %q", got, "lab-1")
+		}
+		_, _ = w.Write([]byte(`{"id":"stage/1","title":"Uno"}`))
+	}, WithTokenSource(tokens), WithUserAgent("missions-test"), WithHeader("X-Lab", "lab-1"))
+
+	stage, err := api.GetStage(context.Background(), "stage/1")
+	if err != nil {
+		t.Fatalf("GetStage: %v", err)
+	}
+	if stage.Title != "Uno" {
+		t.Errorf("title = %q, want %q", stage.Title, "Uno")
+	}
+	if want := api.BaseURL() + "/commands/stage%2F1"; requestURL != want {
+		t.Errorf("token source got URL %q, want %q", requestURL, want)
+	}
+}
+
+func TestClientTokenSourceError(t *testing.T) {
+	tokenErr := errors.New("no session")
+	requests := 0
+	api := newTestClient(t, func(http.ResponseWriter, *http.Request) { requests++ },
+		WithTokenSource(TokenSourceFunc(func(context.Context, string) (string, error) { return "", tokenErr })))
+
+	if _, err := api.GetStage(context.Background(), "1"); !errors.Is(err, tokenErr) {
+		t.Errorf("GetStage error = %v, want the token source error", err)
+	}
+	if requests != 0 {
+		t.Errorf("requests = %d, want none without a token", requests)
+	}
+}
+
+func TestClientErrorTypes(t *testing.T) {
+	t.Run("unauthorized", func(t *testing.T) {
+		api := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="expired"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+
+		_, err := api.GetStage(context.Background(), "1")
+		var unauthorized *UnauthorizedError
+		if !errors.As(err, &unauthorized) {
+			t.Fatalf("GetStage error = %v, want an *UnauthorizedError", err)
+		}
+		if unauthorized.Challenge["error"] != "invalid_token" || unauthorized.Challenge["error_description"] != "expired" {
+			t.Errorf("challenge = %v", unauthorized.Challenge)
+		}
+	})
+
+	t.Run("status with retry after", func(t *testing.T) {
+		api := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("  down for maintenance\n"))
+		})
+
+		_, err := api.GetStage(context.Background(), "1")
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) {
+			t.Fatalf("GetStage error = %v, want a *StatusError", err)
+		}
+		if statusErr.StatusCode != http.StatusServiceUnavailable || statusErr.Body != "down for maintenance" ||
+			statusErr.RetryAfter != 7*time.Second {
+			t.Errorf("status error = %+v", statusErr)
+		}
+		if got := statusErr.Error(); got != "request failed with status 503: down for maintenance" {
+			t.Errorf("Error() = %q", got)
+		}
+	})
+
+	t.Run("long error body is cut", func(t *testing.T) {
+		api := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(strings.Repeat("x", 4*maxErrorBodyLength)))
+		})
+
+		_, err := api.GetStage(context.Background(), "1")
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || len(statusErr.Body) != maxErrorBodyLength {
+			t.Errorf("GetStage error = %v, want a *StatusError with a %d byte body", err, maxErrorBodyLength)
+		}
+	})
+
+	t.Run("not modified without a condition", func(t *testing.T) {
+		api := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotModified)
+		})
+
+		_, err := api.GetStage(context.Background(), "1")
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotModified {
+			t.Errorf("GetStage error = %v, want a *StatusError with status 304", err)
+		}
+	})
+
+	t.Run("hook error", func(t *testing.T) {
+		hookErr := errors.New("unsupported API version")
+		api := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"id":"1"}`))
+		}, WithResponseHook(func(*http.Response) error { return hookErr }))
+
+		if _, err := api.GetStage(context.Background(), "1"); !errors.Is(err, hookErr) {
+			t.Errorf("GetStage error = %v, want the hook error", err)
+		}
+	})
+}
+
+func TestGetStageConditional(t *testing.T) {
+	api := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Mar 2026 10:00:00 GMT")
+		_, _ = w.Write([]byte(`{"id":"1","title":"Uno"}`))
+	})
+
+	stage, err := api.GetStage(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("GetStage: %v", err)
+	}
+	if stage.NotModified || stage.ETag != `"v1"` || stage.LastModified != "Mon, 02 Mar 2026 10:00:00 GMT" {
+		t.Errorf("stage = %+v, want the definition with its validators", stage)
+	}
+
+	stage, err = api.GetStage(context.Background(), "1", IfNoneMatch(`"v1"`))
+	if err != nil {
+		t.Fatalf("GetStage with If-None-Match: %v", err)
+	}
+	if !stage.NotModified || stage.Title != "" {
+		t.Errorf("stage = %+v, want it not modified", stage)
+	}
+}
+
+func TestClientSendsOnceWithoutRetry(t *testing.T) {
+	requests := 0
+	api := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	if _, err := api.GetStage(context.Background(), "1"); err == nil {
+		t.Fatal("GetStage succeeded against a failing server")
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1", requests)
+	}
+}
+
+func TestClientRetriesEachRequest(t *testing.T) {
+	// Every request fails the first time it is sent.
+	retry := &retryTimes{times: 2}
+	var mu sync.Mutex
+	seen := map[string]int{}
+	api := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen[r.URL.RawQuery]++
+		first := seen[r.URL.RawQuery] == 1
+		mu.Unlock()
+
+		if first {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		page := r.URL.Query().Get("page")
+		_, _ = w.Write([]byte(`{"items":[{"id":"m` + page + `"}],"page":` + page + `,"totalPages":3}`))
+	}, WithRetry(retry.retry))
+
+	missions, err := api.ListMissions(context.Background())
+	if err != nil {
+		t.Fatalf("ListMissions: %v", err)
+	}
+	if len(missions) != 3 || missions[0].ID != "m1" || missions[2].ID != "m3" {
+		t.Errorf("missions = %+v, want one per page", missions)
+	}
+	// A failed page is retried on its own instead of starting the listing over.
+	if retry.calls != 3 {
+		t.Errorf("retry calls = %d, want one per page", retry.calls)
+	}
+	for query, count := range seen {
+		if count != 2 {
+			t.Errorf("requests for %s = %d, want 2", query, count)
+		}
+	}
+}
+
+func TestClientRetryGivesUp(t *testing.T) {
+	requests := 0
+	retry := &retryTimes{times: 3}
+	api := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, WithRetry(retry.retry))
+
+	_, err := api.Submit(context.Background(), "1", []string{"ok"}, WithIdempotencyKey("key"))
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Submit error = %v, want the last *StatusError", err)
+	}
+	if requests != 3 {
+		t.Errorf("requests = %d, want 3", requests)
+	}
+}
+
+func TestAdvertisesGzip(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   bool
+	}{
+		{values: nil, want: false},
+		{values: []string{"gzip"}, want: true},
+		{values: []string{"br, GZIP"}, want: true},
+		{values: []string{"identity", "gzip;q=0.5"}, want: true},
+		{values: []string{"gzip; q=0"}, want: false},
+		{values: []string{"gzip;q=0.0"}, want: false},
+		{values: []string{"deflate, br"}, want: false},
+		{values: []string{"x-gzip"}, want: false},
+	}
+
+	for _, tt := range tests {
+		header := http.Header{"Accept-Encoding": tt.values}
+		if got := advertisesGzip(header); got != tt.want {
+			t.Errorf("advertisesGzip(%q) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
+
+// submission is a request body received by gzipServer.
+type submission struct {
+	gzipped bool
+	results []string
+}
+
+// gzipServer records the submissions it receives. It advertises gzip request bodies when advertise
+// is set and answers 415 to compressed bodies when reject is set.
+type gzipServer struct {
+	t         *testing.T
+	mu        sync.Mutex
+	advertise bool
+	reject    bool
+	received  []submission
+}
+
+func (s *gzipServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.advertise {
+		w.Header().Set("Accept-Encoding", "gzip")
+	}
+	if r.Method == http.MethodGet {
+		_, _ = w.Write([]byte(`{"id":"1"}`))
+		return
+	}
+
+	gzipped := r.Header.Get("Content-Encoding") == "gzip"
+	if gzipped && s.reject {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body := io.Reader(r.Body)
+	if gzipped {
+		reader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			s.t.Errorf("the compressed body is not gzip: %v", err)
+			return
+		}
+		body = reader
+	}
+	var payload struct {
+		Results []string `json:"results"`
+	}
+	if err := json.NewDecoder(body).Decode(&payload); err != nil {
+		s.t.Errorf("request body: %v", err)
+	}
+	s.received = append(s.received, submission{gzipped: gzipped, results: payload.Results})
+
+	_, _ = w.Write([]byte(`{"isValid":true,"percentageCorrect":100,"requiredCorrectPercentage":100,"commands":[]}`))
+}
+
+func (s *gzipServer) submissions() []submission {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]submission(nil), s.received...)
+}
+
+func submitLarge(t *testing.T, api *Client) {
+	t.Helper()
+	if _, err := api.Submit(context.Background(), "1", []string{strings.Repeat("x", 64)}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+}
+
+func TestClientCompressesOnceAdvertised(t *testing.T) {
+	server := &gzipServer{t: t, advertise: true}
+	api := newTestClient(t, server.ServeHTTP, WithCompressionThreshold(32))
+
+	// Nothing is compressed before the server says it accepts gzip.
+	submitLarge(t, api)
+	submitLarge(t, api)
+	if _, err := api.Submit(context.Background(), "1", []string{"ok"}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	got := server.submissions()
+	want := []bool{false, true, false}
+	for i, sub := range got {
+		if sub.gzipped != want[i] {
+			t.Errorf("submission %d gzipped = %v, want %v", i, sub.gzipped, want[i])
+		}
+		if len(sub.results) != 1 {
+			t.Errorf("submission %d results = %q, want the results sent", i, sub.results)
+		}
+	}
+}
+
+func TestClientCompressionDisabled(t *testing.T) {
+	server := &gzipServer{t: t, advertise: true}
+	api := newTestClient(t, server.ServeHTTP, WithCompressionThreshold(0))
+
+	submitLarge(t, api)
+	submitLarge(t, api)
+	for i, sub := range server.submissions() {
+		if sub.gzipped {
+			t.Errorf("submission %d was compressed with compression disabled", i)
+		}
+	}
+}
+
+func TestClientFallsBackWhenGzipIsRejected(t *testing.T) {
+	server := &gzipServer{t: t, advertise: true}
+	api := newTestClient(t, server.ServeHTTP, WithCompressionThreshold(32))
+
+	// Learn that the server accepts gzip, then have it stop accepting it.
+	if _, err := api.GetStage(context.Background(), "1"); err != nil {
+		t.Fatalf("GetStage: %v", err)
+	}
+	server.mu.Lock()
+	server.advertise, server.reject = false, true
+	server.mu.Unlock()
+
+	// The rejected submission is sent again uncompressed, within the same call.
+	submitLarge(t, api)
+	// Later submissions are not compressed until the server advertises gzip again.
+	submitLarge(t, api)
+
+	got := server.submissions()
+	if len(got) != 2 {
+		t.Fatalf("accepted submissions = %d, want 2", len(got))
+	}
+	for i, sub := range got {
+		if sub.gzipped {
+			t.Errorf("submission %d was compressed after the server rejected gzip", i)
+		}
+		if len(sub.results) != 1 || sub.results[0] != strings.Repeat("x", 64) {
+			t.Errorf("submission %d results = %q", i, sub.results)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UnauthorizedError is returned when the server rejects the access token.
+type UnauthorizedError struct {
+	// Challenge holds the parameters of the WWW-Authenticate Bearer challenge, if any.
+	Challenge map[string]string
+}
+
+func (e *UnauthorizedError) Error() string {
+	message := "the server rejected the authentication token"
+	if code := e.Challenge["error"]; code != "" {
+		message += ": " + code
+	}
+	if description := e.Challenge["error_description"]; description != "" {
+		message += " (" + description + ")"
+	}
+	return message
+}
+
+// StatusError is returned when the server answers with an unexpected status code.
+type StatusError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter is the delay requested by the server through the Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// parseBearerChallenge extracts the auth-params of a Bearer WWW-Authenticate header (RFC 6750, section 3).
+func parseBearerChallenge(header string) map[string]string {
+	params := make(map[string]string)
+
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return params
+	}
+
+	for rest != "" {
+		var pair string
+		rest = strings.TrimLeft(rest, " ,")
+		key, value, found := strings.Cut(rest, "=")
+		if !found {
+			break
+		}
+		key = strings.TrimSpace(key)
+		// Whitespace is allowed around the equals sign (RFC 9110, section 11.2).
+		value = strings.TrimLeft(value, " \t")
+
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[key] = value[1:]
+				break
+			}
+			pair, rest = value[1:end+1], value[end+2:]
+		} else {
+			pair, rest, _ = strings.Cut(value, ",")
+		}
+		params[key] = strings.TrimSpace(pair)
+	}
+
+	return params
+}
+
+// parseRetryAfter reads a Retry-After header, given either in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
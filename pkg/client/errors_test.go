@@ -0,0 +1,113 @@
+package client
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseBearerChallenge(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   map[string]string
+	}{
+		{name: "empty", header: "", want: map[string]string{}},
+		{name: "scheme only", header: "Bearer", want: map[string]string{}},
+		{name: "other scheme", header: `Basic realm="missions"`, want: map[string]string{}},
+		{
+			name:   "quoted parameters",
+			header: `Bearer realm="missions", error="invalid_token", error_description="The access token expired"`,
+			want: map[string]string{
+				"realm": "missions", "error": "invalid_token", "error_description": "The access token expired",
+			},
+		},
+		{
+			name:   "lower case scheme and token parameters",
+			header: `bearer error=insufficient_scope, scope="missions:read missions:write"`,
+			want:   map[string]string{"error": "insufficient_scope", "scope": "missions:read missions:write"},
+		},
+		{
+			name:   "comma inside quotes",
+			header: `Bearer error="invalid_token", error_description="expired, log in again"`,
+			want:   map[string]string{"error": "invalid_token", "error_description": "expired, log in again"},
+		},
+		{
+			name:   "extra spaces",
+			header: `  Bearer   error = "invalid_token" ,  realm="missions"  `,
+			want:   map[string]string{"error": "invalid_token", "realm": "missions"},
+		},
+		{
+			name:   "unterminated quote",
+			header: `Bearer error="invalid_token", error_description="never closed`,
+			want:   map[string]string{"error": "invalid_token", "error_description": "never closed"},
+		},
+		{
+			name:   "parameter without value",
+			header: `Bearer error="invalid_token", stray`,
+			want:   map[string]string{"error": "invalid_token"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseBearerChallenge(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseBearerChallenge(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, time.March, 2, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "empty", value: "", want: 0},
+		{name: "seconds", value: "120", want: 2 * time.Minute},
+		{name: "seconds with spaces", value: " 5 ", want: 5 * time.Second},
+		{name: "zero", value: "0", want: 0},
+		{name: "negative", value: "-10", want: 0},
+		{name: "HTTP date in the future", value: now.Add(90 * time.Second).Format(http.TimeFormat), want: 90 * time.Second},
+		{name: "HTTP date in the past", value: now.Add(-time.Hour).Format(http.TimeFormat), want: 0},
+		{name: "RFC 850 date", value: "Monday, 02-Mar-26 10:00:30 GMT", want: 30 * time.Second},
+		{name: "fractional seconds", value: "1.5", want: 0},
+		{name: "garbage", value: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnauthorizedErrorMessage(t *testing.T) {
+	tests := []struct {
+		challenge map[string]string
+		want      string
+	}{
+		{challenge: nil, want: "the server rejected the authentication token"},
+		{
+			challenge: map[string]string{"error": "invalid_token"},
+			want:      "the server rejected the authentication token: invalid_token",
+		},
+		{
+			challenge: map[string]string{"error": "invalid_token", "error_description": "expired"},
+			want:      "the server rejected the authentication token: invalid_token (expired)",
+		},
+	}
+
+	for _, tt := range tests {
+		err := &UnauthorizedError{Challenge: tt.challenge}
+		if got := err.Error(); got != tt.want {
+			t.Errorf("Error() with challenge %v = %q, want %q", tt.challenge, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SchemaError describes a server response that does not have the expected shape
+type SchemaError struct {
+	// Path locates the offending field, for example "commands[1].isCorrect"
+	Path    string
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("unexpected response from the server: %s", e.Message)
+	}
+	return fmt.Sprintf("unexpected response from the server: field %q %s", e.Path, e.Message)
+}
+
+// validationResponseFields mirrors ValidationResponse with every field optional, to tell
+// missing and null fields apart from zero values.
+type validationResponseFields struct {
+	IsValid                   *bool             `json:"isValid"`
+	PercentageCorrect         *float64          `json:"percentageCorrect"`
+	RequiredCorrectPercentage *float64          `json:"requiredCorrectPercentage"`
+	Commands                  []json.RawMessage `json:"commands"`
+}
+
+type commandVerdictFields struct {
+	Command   *string `json:"command"`
+	IsCorrect *bool   `json:"isCorrect"`
+}
+
+// DecodeValidationResponse decodes and validates the response of the validate and submit endpoints.
+// Unknown fields are ignored; missing, null or mistyped fields are reported as a *SchemaError.
+func DecodeValidationResponse(data []byte) (*ValidationResponse, error) {
+	var fields validationResponseFields
+	if err := decodeObject(data, "", &fields); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case fields.IsValid == nil:
+		return nil, missingField("isValid")
+	case fields.PercentageCorrect == nil:
+		return nil, missingField("percentageCorrect")
+	case fields.RequiredCorrectPercentage == nil:
+		return nil, missingField("requiredCorrectPercentage")
+	case fields.Commands == nil:
+		return nil, missingField("commands")
+	}
+
+	response := &ValidationResponse{
+		IsValid:                   *fields.IsValid,
+		PercentageCorrect:         *fields.PercentageCorrect,
+		RequiredCorrectPercentage: *fields.RequiredCorrectPercentage,
+		Commands:                  make([]CommandVerdict, 0, len(fields.Commands)),
+	}
+
+	for i, raw := range fields.Commands {
+		path := fmt.Sprintf("commands[%d]", i)
+
+		var verdict commandVerdictFields
+		if err := decodeObject(raw, path, &verdict); err != nil {
+			return nil, err
+		}
+		if verdict.Command == nil {
+			return nil, missingField(path + ".command")
+		}
+		if verdict.IsCorrect == nil {
+			return nil, missingField(path + ".isCorrect")
+		}
+
+		response.Commands = append(response.Commands, CommandVerdict{
+			Command:   *verdict.Command,
+			IsCorrect: *verdict.IsCorrect,
+		})
+	}
+
+	return response, nil
+}
+
+// decodeObject unmarshals a JSON object found at path into v, turning decoding errors into a *SchemaError.
+func decodeObject(data []byte, path string, v any) error {
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(trimmed, []byte("{")) {
+		if path == "" {
+			return &SchemaError{Message: "expected a JSON object"}
+		}
+		return &SchemaError{Path: path, Message: "must be an object"}
+	}
+
+	err := json.Unmarshal(trimmed, v)
+	if err == nil {
+		return nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &SchemaError{
+			Path:    joinPath(path, typeErr.Field),
+			Message: fmt.Sprintf("must be of type %s, got %s", jsonTypeName(typeErr.Type.Kind().String()), typeErr.Value),
+		}
+	}
+	return &SchemaError{Path: path, Message: fmt.Sprintf("is not valid JSON: %v", err)}
+}
+
+func missingField(path string) *SchemaError {
+	return &SchemaError{Path: path, Message: "is missing or null"}
+}
+
+func joinPath(parent, field string) string {
+	switch {
+	case parent == "":
+		return field
+	case field == "":
+		return parent
+	default:
+		return parent + "." + field
+	}
+}
+
+// jsonTypeName names a Go kind the way the JSON in the response would.
+func jsonTypeName(kind string) string {
+	switch kind {
+	case "bool":
+		return "boolean"
+	case "float64", "float32", "int", "int64":
+		return "number"
+	case "string":
+		return "string"
+	case "slice":
+		return "array"
+	case "struct", "map":
+		return "object"
+	default:
+		return kind
+	}
+}
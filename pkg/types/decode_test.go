@@ -0,0 +1,201 @@
+package types
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const validResponse = `{
+	"isValid": true,
+	"percentageCorrect": 50,
+	"requiredCorrectPercentage": 40,
+	"commands": [
+		{"command": "ls", "isCorrect": true},
+		{"command": "pwd", "isCorrect": false, "hint": "unknown fields are ignored"}
+	],
+	"extra": {"ignored": true}
+}`
+
+func TestDecodeValidationResponse(t *testing.T) {
+	got, err := DecodeValidationResponse([]byte(validResponse))
+	if err != nil {
+		t.Fatalf("DecodeValidationResponse: %v", err)
+	}
+
+	want := &ValidationResponse{
+		IsValid:                   true,
+		PercentageCorrect:         50,
+		RequiredCorrectPercentage: 40,
+		Commands: []CommandVerdict{
+			{Command: "ls", IsCorrect: true},
+			{Command: "pwd", IsCorrect: false},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeValidationResponse = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeValidationResponseEmptyCommands(t *testing.T) {
+	got, err := DecodeValidationResponse([]byte(
+		`{"isValid": false, "percentageCorrect": 0, "requiredCorrectPercentage": 100, "commands": []}`))
+	if err != nil {
+		t.Fatalf("DecodeValidationResponse: %v", err)
+	}
+	if got.Commands == nil || len(got.Commands) != 0 {
+		t.Errorf("commands = %#v, want an empty list", got.Commands)
+	}
+}
+
+func TestDecodeValidationResponseSchemaErrors(t *testing.T) {
+	// withField returns the valid response fields with one of them replaced, or removed if value is empty.
+	withField := func(field, value string) string {
+		fields := map[string]string{
+			"isValid":                   `true`,
+			"percentageCorrect":         `50`,
+			"requiredCorrectPercentage": `40`,
+			"commands":                  `[{"command": "ls", "isCorrect": true}]`,
+		}
+		if value == "" {
+			delete(fields, field)
+		} else {
+			fields[field] = value
+		}
+		var pairs []string
+		for _, key := range []string{"isValid", "percentageCorrect", "requiredCorrectPercentage", "commands"} {
+			if value, ok := fields[key]; ok {
+				pairs = append(pairs, `"`+key+`": `+value)
+			}
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	}
+
+	tests := []struct {
+		name        string
+		body        string
+		wantPath    string
+		wantMessage string
+	}{
+		{name: "missing isValid", body: withField("isValid", ""), wantPath: "isValid", wantMessage: "is missing or null"},
+		{name: "null isValid", body: withField("isValid", "null"), wantPath: "isValid", wantMessage: "is missing or null"},
+		{
+			name: "isValid as a string", body: withField("isValid", `"yes"`),
+			wantPath: "isValid", wantMessage: "must be of type boolean, got string",
+		},
+		{
+			name: "missing percentageCorrect", body: withField("percentageCorrect", ""),
+			wantPath: "percentageCorrect", wantMessage: "is missing or null",
+		},
+		{
+			name: "percentageCorrect as a string", body: withField("percentageCorrect", `"50%"`),
+			wantPath: "percentageCorrect", wantMessage: "must be of type number, got string",
+		},
+		{
+			name: "null requiredCorrectPercentage", body: withField("requiredCorrectPercentage", "null"),
+			wantPath: "requiredCorrectPercentage", wantMessage: "is missing or null",
+		},
+		{
+			name: "requiredCorrectPercentage as an object", body: withField("requiredCorrectPercentage", `{"value": 40}`),
+			wantPath: "requiredCorrectPercentage", wantMessage: "must be of type number, got object",
+		},
+		{name: "missing commands", body: withField("commands", ""), wantPath: "commands", wantMessage: "is missing or null"},
+		{name: "null commands", body: withField("commands", "null"), wantPath: "commands", wantMessage: "is missing or null"},
+		{
+			name: "commands as an object", body: withField("commands", `{"command": "ls"}`),
+			wantPath: "commands", wantMessage: "must be of type array, got object",
+		},
+		{
+			name: "null command verdict", body: withField("commands", `[{"command": "ls", "isCorrect": true}, null]`),
+			wantPath: "commands[1]", wantMessage: "must be an object",
+		},
+		{
+			name: "command verdict as a string", body: withField("commands", `["ls"]`),
+			wantPath: "commands[0]", wantMessage: "must be an object",
+		},
+		{
+			name: "missing command", body: withField("commands", `[{"command": "ls", "isCorrect": true}, {"isCorrect": true}]`),
+			wantPath: "commands[1].command", wantMessage: "is missing or null",
+		},
+		{
+			name: "null isCorrect", body: withField("commands", `[{"command": "ls", "isCorrect": null}]`),
+			wantPath: "commands[0].isCorrect", wantMessage: "is missing or null",
+		},
+		{
+			name: "isCorrect as a number", body: withField("commands", `[{"command": "ls", "isCorrect": true}, {"command": "pwd", "isCorrect": 1}]`),
+			wantPath: "commands[1].isCorrect", wantMessage: "must be of type boolean, got number",
+		},
+		{
+			name: "command as a list", body: withField("commands", `[{"command": ["ls"], "isCorrect": true}]`),
+			wantPath: "commands[0].command", wantMessage: "must be of type string, got array",
+		},
+		{name: "array body", body: `[{"isValid": true}]`, wantMessage: "expected a JSON object"},
+		{name: "string body", body: `"ok"`, wantMessage: "expected a JSON object"},
+		{name: "null body", body: `null`, wantMessage: "expected a JSON object"},
+		{name: "empty body", body: ``, wantMessage: "expected a JSON object"},
+		{name: "HTML body", body: `<html>Bad Gateway</html>`, wantMessage: "expected a JSON object"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := DecodeValidationResponse([]byte(tt.body))
+			if response != nil {
+				t.Errorf("DecodeValidationResponse returned %+v along with the error", response)
+			}
+
+			var schemaErr *SchemaError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("DecodeValidationResponse(%s) error = %v, want a *SchemaError", tt.body, err)
+			}
+			if schemaErr.Path != tt.wantPath || schemaErr.Message != tt.wantMessage {
+				t.Errorf("SchemaError = {Path: %q, Message: %q}, want {Path: %q, Message: %q}",
+					schemaErr.Path, schemaErr.Message, tt.wantPath, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestDecodeValidationResponseInvalidJSON(t *testing.T) {
+	_, err := DecodeValidationResponse([]byte(`{"isValid": true,`))
+
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("error = %v, want a *SchemaError", err)
+	}
+	if schemaErr.Path != "" || !strings.HasPrefix(schemaErr.Message, "is not valid JSON: ") {
+		t.Errorf("SchemaError = {Path: %q, Message: %q}, want an invalid JSON error", schemaErr.Path, schemaErr.Message)
+	}
+}
+
+func TestSchemaErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  *SchemaError
+		want string
+	}{
+		{
+			err:  &SchemaError{Message: "expected a JSON object"},
+			want: "unexpected response from the server: expected a JSON object",
+		},
+		{
+			err:  &SchemaError{Path: "commands[2].isCorrect", Message: "is missing or null"},
+			want: `unexpected response from the server: field "commands[2].isCorrect" is missing or null`,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestDecodeValidationResponseErrorMessage(t *testing.T) {
+	_, err := DecodeValidationResponse([]byte(
+		`{"isValid": true, "percentageCorrect": 50, "requiredCorrectPercentage": 40, "commands": [{"command": "ls", "isCorrect": "yes"}]}`))
+
+	want := `unexpected response from the server: field "commands[0].isCorrect" must be of type boolean, got string`
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
@@ -2,14 +2,64 @@ package types
 
 // Command represents a remote command to be executed
 type Command struct {
-	ID      string `json:"id"`
-	Title   string `json:"title"`
-	Description string `json:"description"`
-	Commands []string `json:"commands"`
+	ID                        string   `json:"id"`
+	Title                     string   `json:"title"`
+	Description               string   `json:"description"`
+	Commands                  []string `json:"commands"`
+	RequiredCorrectPercentage float64  `json:"requiredCorrectPercentage,omitempty"`
 }
 
 // CommandResult represents the result of a command execution
 type CommandResult struct {
-	ID     string `json:"id"`
+	ID      string   `json:"id"`
 	Results []string `json:"results"`
 }
+
+// ValidationResponse represents the verdict of the server on the results of a stage
+type ValidationResponse struct {
+	IsValid                   bool             `json:"isValid"`
+	PercentageCorrect         float64          `json:"percentageCorrect"`
+	RequiredCorrectPercentage float64          `json:"requiredCorrectPercentage"`
+	Commands                  []CommandVerdict `json:"commands"`
+}
+
+// CommandVerdict represents the verdict on a single command of a stage
+type CommandVerdict struct {
+	Command   string `json:"command"`
+	IsCorrect bool   `json:"isCorrect"`
+}
+
+// Mission represents a mission the student is enrolled in
+type Mission struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// Progress of a student on a stage
+const (
+	StageNotStarted = "not_started"
+	StageFailed     = "failed"
+	StageCompleted  = "completed"
+)
+
+// StageSummary represents a stage of a mission and the progress of the student on it
+type StageSummary struct {
+	ID             string   `json:"id"`
+	Title          string   `json:"title"`
+	Status         string   `json:"status"`
+	LastPercentage *float64 `json:"lastPercentage,omitempty"`
+}
+
+// MissionPage represents a page of the missions of the student
+type MissionPage struct {
+	Items      []Mission `json:"items"`
+	Page       int       `json:"page"`
+	TotalPages int       `json:"totalPages"`
+}
+
+// StageSummaryPage represents a page of the stages of a mission
+type StageSummaryPage struct {
+	Items      []StageSummary `json:"items"`
+	Page       int            `json:"page"`
+	TotalPages int            `json:"totalPages"`
+}